@@ -1,15 +1,39 @@
 package main
 
 import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
 	"encoding/json"
+	"encoding/pem"
 	"fmt"
+	"hyperledger_dapp/controller"
+	"hyperledger_dapp/decode"
+	"hyperledger_dapp/identity"
 	"hyperledger_dapp/model"
 	"hyperledger_dapp/repository"
+	"hyperledger_dapp/signature"
+	"hyperledger_dapp/util"
+	"math/big"
+	"os"
 	"strconv"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes/timestamp"
 	"github.com/hyperledger/fabric-chaincode-go/shim"
 	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+	"github.com/hyperledger/fabric-protos-go/msp"
+	sc "github.com/hyperledger/fabric-protos-go/peer"
 )
 
 const (
@@ -17,47 +41,178 @@ const (
 	initSymbol    = "dt"
 	initOwner     = "dappcampus"
 	initAmount    = 1000000
+	initDecimals  = "2"
 	txMint        = "txMint"
 )
 
 var function = []byte("mint")
 
-func configuration() *shimtest.MockStub {
+// configuration initializes the chaincode with a fresh owner identity as submitter
+func configuration(t *testing.T) (*shimtest.MockStub, *testIdentity) {
+	owner := newIdentity(t, "Org1MSP", initOwner)
 	cc := NewChaincode()
 	stub := shimtest.NewMockStub("erc20", cc)
-	stub.MockInit("1", [][]byte{[]byte("Init"), []byte(initTokenName), []byte(initSymbol), []byte(initOwner), []byte(strconv.Itoa(initAmount))})
+	stub.Creator = owner.creator
+	stub.MockInit("1", [][]byte{[]byte("Init"), []byte(initTokenName), []byte(initSymbol), []byte(owner.address), []byte(strconv.Itoa(initAmount)), []byte(initDecimals)})
 
-	return stub
+	// drop the event of the initial supply
+	<-stub.ChaincodeEventsChannel
+
+	return stub, owner
+}
+
+type testIdentity struct {
+	creator []byte
+	address string
+	key     *ecdsa.PrivateKey
+}
+
+// newIdentity makes a self-signed X.509 identity for the mock stub creator
+func newIdentity(t *testing.T, mspID, commonName string) *testIdentity {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER})
+	creator, err := proto.Marshal(&msp.SerializedIdentity{Mspid: mspID, IdBytes: certPEM})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stub := shimtest.NewMockStub("identity", nil)
+	stub.Creator = creator
+	address, err := identity.GetCallerAddress(stub)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &testIdentity{creator: creator, address: address, key: key}
+}
+
+// invokeAs invokes the chaincode with the identity as submitter
+// the proposal names the chaincode as stub.Name
+func invokeAs(stub *shimtest.MockStub, id *testIdentity, txID string, args ...string) sc.Response {
+	stub.Creator = id.creator
+	arguments := [][]byte{}
+	for _, arg := range args {
+		arguments = append(arguments, []byte(arg))
+	}
+	return stub.MockInvokeWithSignedProposal(txID, arguments, signedProposal(stub.Name, arguments))
+}
+
+// signedProposal makes a proposal invoking chaincodeName with args
+func signedProposal(chaincodeName string, args [][]byte) *sc.SignedProposal {
+	extension, _ := proto.Marshal(&sc.ChaincodeHeaderExtension{ChaincodeId: &sc.ChaincodeID{Name: chaincodeName}})
+	channelHeader, _ := proto.Marshal(&common.ChannelHeader{Extension: extension})
+	header, _ := proto.Marshal(&common.Header{ChannelHeader: channelHeader})
+	input, _ := proto.Marshal(&sc.ChaincodeInvocationSpec{ChaincodeSpec: &sc.ChaincodeSpec{Input: &sc.ChaincodeInput{Args: args}}})
+	payload, _ := proto.Marshal(&sc.ChaincodeProposalPayload{Input: input})
+	proposal, _ := proto.Marshal(&sc.Proposal{Header: header, Payload: payload})
+	return &sc.SignedProposal{ProposalBytes: proposal}
+}
+
+// calledChaincode runs a chaincode which the chaincode of caller calls through stub.InvokeChaincode
+// a call in the transaction of caller gets the creator and the proposal of that transaction
+type calledChaincode struct {
+	shim.Chaincode
+	caller *shimtest.MockStub
+}
+
+func (cc *calledChaincode) Invoke(stub shim.ChaincodeStubInterface) sc.Response {
+	if stub.GetTxID() != cc.caller.GetTxID() {
+		return cc.Chaincode.Invoke(stub)
+	}
+	return cc.Chaincode.Invoke(&calledStub{ChaincodeStubInterface: stub, caller: cc.caller})
+}
+
+type calledStub struct {
+	shim.ChaincodeStubInterface
+	caller *shimtest.MockStub
+}
+
+func (stub *calledStub) GetCreator() ([]byte, error) {
+	return stub.caller.GetCreator()
+}
+
+func (stub *calledStub) GetSignedProposal() (*sc.SignedProposal, error) {
+	return stub.caller.GetSignedProposal()
+}
+
+// forwardChaincode calls target with args whatever it is invoked with
+type forwardChaincode struct {
+	target string
+	args   [][]byte
+}
+
+func (cc *forwardChaincode) Init(stub shim.ChaincodeStubInterface) sc.Response {
+	return shim.Success(nil)
+}
+
+func (cc *forwardChaincode) Invoke(stub shim.ChaincodeStubInterface) sc.Response {
+	return stub.InvokeChaincode(cc.target, cc.args, stub.GetChannelID())
+}
+
+// nextEvents decodes the next event envelope emitted by the chaincode
+func nextEvents(t *testing.T, stub *shimtest.MockStub) []model.EventRecord {
+	data := <-stub.ChaincodeEventsChannel
+	if data.GetEventName() != repository.EnvelopeEventKey {
+		t.Fatal("unexpected event", data.GetEventName())
+	}
+
+	events, err := decode.Events(data.GetEventName(), data.Payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return events
 }
 
 func TestInit(t *testing.T) {
 	cc := NewChaincode()
 	stub := shimtest.NewMockStub("erc20", cc)
-	res := stub.MockInit("1", [][]byte{[]byte("Init"), []byte(initTokenName), []byte(initSymbol), []byte(initOwner), []byte(strconv.Itoa(initAmount))})
+	res := stub.MockInit("1", [][]byte{[]byte("Init"), []byte(initTokenName), []byte(initSymbol), []byte(initOwner), []byte(strconv.Itoa(initAmount)), []byte(initDecimals)})
 	if res.Status != shim.OK {
 		t.Error("init failed", res.Status, res.Message)
 	}
 
 	// check totalSupply
-	erc20 := model.ERC20Metadata{}
-	erc20Bytes, _ := stub.GetState(initTokenName)
-	json.Unmarshal(erc20Bytes, &erc20)
-	totalSupply := *erc20.GetTotalSupply()
-	if initAmount != totalSupply {
+	erc20, _ := repository.GetERC20Metadata(stub, initTokenName)
+	totalSupply := erc20.GetTotalSupply()
+	if totalSupply.Int64() != initAmount {
 		t.FailNow()
 	}
 
-	balance, _ := repository.GetBalance(stub, initOwner, false)
-
-	fmt.Println(*balance)
+	// initial supply is assigned to owner
+	balance, _ := repository.GetBalance(stub, initTokenName, initOwner, false)
+	if balance.Int64() != initAmount {
+		t.Fatal("unexpected owner balance", balance)
+	}
 
-	// check dappcampus balance
+	// initial supply is minted to owner
+	events := nextEvents(t, stub)
+	transferEvent, err := decode.Transfer(events[0])
+	if err != nil || transferEvent.Sender != identity.ZeroAddress || transferEvent.Recipient != initOwner || transferEvent.Amount != strconv.Itoa(initAmount) {
+		t.Fatal("unexpected init event", events)
+	}
 }
 
 func TestMint(t *testing.T) {
-	stub := configuration()
+	stub, owner := configuration(t)
 	const increasAmount = 100000
-	arguments := [][]byte{function, []byte(initTokenName), []byte(initOwner), []byte(strconv.Itoa(increasAmount))}
+	arguments := [][]byte{function, []byte(initTokenName), []byte(owner.address), []byte(strconv.Itoa(increasAmount))}
+
+	// owner of the token is its first minter
 	res := stub.MockInvoke(txMint, arguments)
 	if res.Status != shim.OK {
 		t.FailNow()
@@ -65,34 +220,2003 @@ func TestMint(t *testing.T) {
 
 	// increase total supply
 	totalSupply, _ := repository.GetERC20TotalSupply(stub, initTokenName)
-	if *totalSupply != initAmount+increasAmount {
+	if totalSupply.Int64() != initAmount+increasAmount {
 		t.FailNow()
 	}
 
-	balance, err := repository.GetBalance(stub, initOwner, false)
+	balance, err := repository.GetBalance(stub, initTokenName, owner.address, false)
 	if err != nil {
-		fmt.Println("fail to get balance")
-		t.FailNow()
+		t.Fatal("fail to get balance", err)
 	}
 
-	if *balance != initAmount+increasAmount {
-		fmt.Println("not eqaul balance")
-		t.FailNow()
+	if balance.Int64() != initAmount+increasAmount {
+		t.Fatal("unexpected balance", balance)
 	}
 
 	// emit transfer event
-	data := <-stub.ChaincodeEventsChannel
-	if data.GetEventName() == repository.TransferEventKey {
+	events := nextEvents(t, stub)
+	if len(events) != 1 || events[0].Name != repository.TransferEventKey {
 		t.FailNow()
 	}
 
-	event := model.NewTransferEvent("admin", initTokenName, increasAmount)
+	event := model.NewTransferEvent(initTokenName, identity.ZeroAddress, owner.address, big.NewInt(increasAmount))
 
 	eventBytes, _ := json.Marshal(event)
 
-	if string(data.Payload) != string(eventBytes) {
+	if string(events[0].Payload) != string(eventBytes) {
 		t.FailNow()
 	}
 
-	fmt.Println(string(res.Payload))
+	if string(res.Payload) != "mint success" {
+		t.Fatal("unexpected mint response", string(res.Payload))
+	}
+}
+
+func TestTransfer(t *testing.T) {
+	stub, alice := configuration(t)
+	bob := newIdentity(t, "Org2MSP", "bob")
+
+	res := invokeAs(stub, alice, "txTransfer", "transfer", initTokenName, alice.address, bob.address, "300")
+	if res.Status != shim.OK {
+		t.Fatal("transfer failed", res.Message)
+	}
+
+	aliceBalance, _ := repository.GetBalance(stub, initTokenName, alice.address, true)
+	bobBalance, _ := repository.GetBalance(stub, initTokenName, bob.address, true)
+	if aliceBalance.Int64() != initAmount-300 || bobBalance.Int64() != 300 {
+		t.Fatal("unexpected balances", aliceBalance, bobBalance)
+	}
+
+	// bob cannot move alice's tokens by claiming her address
+	res = invokeAs(stub, bob, "txForged", "transfer", initTokenName, alice.address, bob.address, "100")
+	if res.Status == shim.OK {
+		t.Fatal("forged transfer must be rejected")
+	}
+
+	// bob cannot spend more than approved
+	invokeAs(stub, alice, "txApprove", "approve", initTokenName, alice.address, bob.address, "50")
+	res = invokeAs(stub, bob, "txTransferFrom", "transferFrom", initTokenName, alice.address, bob.address, bob.address, "60")
+	if res.Status == shim.OK {
+		t.Fatal("transferFrom over allowance must be rejected")
+	}
+
+	res = invokeAs(stub, bob, "txTransferFrom", "transferFrom", initTokenName, alice.address, bob.address, bob.address, "50")
+	if res.Status != shim.OK {
+		t.Fatal("transferFrom failed", res.Message)
+	}
+
+	allowance, _ := repository.GetAllowance(stub, initTokenName, alice.address, bob.address)
+	bobBalance, _ = repository.GetBalance(stub, initTokenName, bob.address, true)
+	if allowance.Sign() != 0 || bobBalance.Int64() != 350 {
+		t.Fatal("unexpected allowance or balance", allowance, bobBalance)
+	}
+}
+
+func TestRoles(t *testing.T) {
+	stub, owner := configuration(t)
+	bob := newIdentity(t, "Org2MSP", "bob")
+
+	// only admin can grant role
+	res := invokeAs(stub, bob, "txGrant", "grantRole", model.MinterRole, bob.address, initTokenName)
+	if res.Status != 403 {
+		t.Fatal("grantRole by non admin must be forbidden", res.Status)
+	}
+
+	res = invokeAs(stub, owner, "txGrant", "grantRole", model.MinterRole, bob.address, initTokenName)
+	if res.Status != shim.OK {
+		t.Fatal("grantRole failed", res.Message)
+	}
+
+	res = invokeAs(stub, bob, "txHasRole", "hasRole", model.MinterRole, bob.address, initTokenName)
+	if string(res.Payload) != "true" {
+		t.Fatal("bob must be minter", string(res.Payload))
+	}
+
+	res = invokeAs(stub, bob, "txMembers", "getRoleMembers", model.AdminRole)
+	if string(res.Payload) != `["`+owner.address+`"]` {
+		t.Fatal("owner must be the only admin", string(res.Payload))
+	}
+
+	// bob renounces own role
+	res = invokeAs(stub, bob, "txRenounce", "renounceRole", model.MinterRole, bob.address, initTokenName)
+	if res.Status != shim.OK {
+		t.Fatal("renounceRole failed", res.Message)
+	}
+
+	res = invokeAs(stub, bob, "txHasRole", "hasRole", model.MinterRole, bob.address, initTokenName)
+	if string(res.Payload) != "false" {
+		t.Fatal("bob must not be minter", string(res.Payload))
+	}
+
+	res = invokeAs(stub, owner, "txRevoke", "revokeRole", "MINTR", bob.address)
+	if res.Status == shim.OK {
+		t.Fatal("revokeRole of unknown role must be rejected")
+	}
+
+	// init of another token can not make the caller admin
+	stub.Creator = bob.creator
+	res = stub.MockInit("txInit", [][]byte{[]byte("Init"), []byte("evilToken"), []byte("EV"), []byte(bob.address), []byte("1"), []byte("0")})
+	if res.Status != 403 {
+		t.Fatal("init by non admin must be forbidden", res.Status)
+	}
+
+	res = invokeAs(stub, bob, "txInit", "init", "evilToken", "EV", bob.address, "1", "0")
+	if res.Status != 404 {
+		t.Fatal("init must not be invoked", res.Status)
+	}
+
+	res = invokeAs(stub, bob, "txHasRole", "hasRole", model.AdminRole, bob.address)
+	if string(res.Payload) != "false" {
+		t.Fatal("bob must not be admin", string(res.Payload))
+	}
+
+	// the last admin can not be removed, so init never sees a chaincode without admin
+	res = invokeAs(stub, owner, "txRenounce", "renounceRole", "ADMN", owner.address)
+	if res.Status == shim.OK {
+		t.Fatal("renounceRole of unknown role must be rejected")
+	}
+
+	res = invokeAs(stub, owner, "txRenounce", "renounceRole", model.AdminRole, owner.address)
+	if res.Status == shim.OK {
+		t.Fatal("the last admin must not renounce")
+	}
+
+	res = invokeAs(stub, owner, "txRevoke", "revokeRole", model.AdminRole, owner.address)
+	if res.Status == shim.OK {
+		t.Fatal("the last admin must not be revoked")
+	}
+
+	invokeAs(stub, owner, "txGrant", "grantRole", model.AdminRole, bob.address)
+	res = invokeAs(stub, owner, "txRenounce", "renounceRole", model.AdminRole, owner.address)
+	if res.Status != shim.OK {
+		t.Fatal("renounceRole of another admin failed", res.Message)
+	}
+
+	res = invokeAs(stub, bob, "txMembers", "getRoleMembers", model.AdminRole)
+	if string(res.Payload) != `["`+bob.address+`"]` {
+		t.Fatal("bob must be the only admin", string(res.Payload))
+	}
+}
+
+func TestBurn(t *testing.T) {
+	stub, owner := configuration(t)
+	bob := newIdentity(t, "Org2MSP", "bob")
+
+	// holder burns own tokens without BURNER role
+	invokeAs(stub, owner, "txTransfer", "transfer", initTokenName, owner.address, bob.address, "100")
+	res := invokeAs(stub, bob, "txBurn", "burn", initTokenName, bob.address, "100")
+	if res.Status != shim.OK {
+		t.Fatal("burn failed", res.Message)
+	}
+
+	res = invokeAs(stub, owner, "txBurn", "burn", initTokenName, owner.address, "100")
+	if res.Status != shim.OK {
+		t.Fatal("burn failed", res.Message)
+	}
+
+	// cannot burn more than balance
+	res = invokeAs(stub, owner, "txBurn", "burn", initTokenName, owner.address, strconv.Itoa(initAmount))
+	if res.Status == shim.OK {
+		t.Fatal("burn over balance must be rejected")
+	}
+
+	// bob burns against allowance once granted BURNER
+	invokeAs(stub, owner, "txApprove", "approve", initTokenName, owner.address, bob.address, "500")
+	res = invokeAs(stub, bob, "txBurnFrom", "burnFrom", initTokenName, owner.address, bob.address, "400")
+	if res.Status != 403 {
+		t.Fatal("burnFrom without BURNER role must be forbidden", res.Status)
+	}
+
+	invokeAs(stub, owner, "txGrant", "grantRole", model.BurnerRole, bob.address, initTokenName)
+	res = invokeAs(stub, bob, "txBurnFrom", "burnFrom", initTokenName, owner.address, bob.address, "501")
+	if res.Status == shim.OK {
+		t.Fatal("burnFrom over allowance must be rejected")
+	}
+
+	res = invokeAs(stub, bob, "txBurnFrom", "burnFrom", initTokenName, owner.address, bob.address, "400")
+	if res.Status != shim.OK {
+		t.Fatal("burnFrom failed", res.Message)
+	}
+
+	// total supply & balance decrease together
+	totalSupply, _ := repository.GetERC20TotalSupply(stub, initTokenName)
+	balance, _ := repository.GetBalance(stub, initTokenName, owner.address, true)
+	allowance, _ := repository.GetAllowance(stub, initTokenName, owner.address, bob.address)
+	if totalSupply.Int64() != initAmount-600 || balance.Int64() != initAmount-600 || allowance.Int64() != 100 {
+		t.Fatal("unexpected supply, balance or allowance", totalSupply, balance, allowance)
+	}
+}
+
+func TestTokenRegistry(t *testing.T) {
+	stub, owner := configuration(t)
+	bob := newIdentity(t, "Org2MSP", "bob")
+
+	// only admin can create token
+	res := invokeAs(stub, bob, "txCreate", "createToken", "otherToken", "ot", bob.address, "100", "0")
+	if res.Status != 403 {
+		t.Fatal("createToken by non admin must be forbidden", res.Status)
+	}
+
+	res = invokeAs(stub, owner, "txCreate", "createToken", "otherToken", "ot", bob.address, "100", "0")
+	if res.Status != shim.OK {
+		t.Fatal("createToken failed", res.Message)
+	}
+
+	res = invokeAs(stub, owner, "txCreate", "createToken", "otherToken", "ot", bob.address, "100", "0")
+	if res.Status == shim.OK {
+		t.Fatal("token cannot be created twice")
+	}
+
+	// a holder named like the token does not collide with metadata
+	res = invokeAs(stub, owner, "txTransfer", "transfer", initTokenName, owner.address, "otherToken", "10")
+	if res.Status != shim.OK {
+		t.Fatal("transfer failed", res.Message)
+	}
+
+	res = invokeAs(stub, bob, "txInfo", "tokenInfo", "otherToken")
+	metadata := model.ERC20Metadata{}
+	json.Unmarshal(res.Payload, &metadata)
+	if metadata.Owner != bob.address || metadata.TotalSupply != "100" {
+		t.Fatal("unexpected metadata", string(res.Payload))
+	}
+
+	// balances are kept per token
+	res = invokeAs(stub, bob, "txBalance", "balanceOf", "otherToken", bob.address)
+	if string(res.Payload) != "100" {
+		t.Fatal("unexpected otherToken balance", string(res.Payload))
+	}
+
+	res = invokeAs(stub, bob, "txBalance", "balanceOf", initTokenName, bob.address)
+	if string(res.Payload) != "0" {
+		t.Fatal("unexpected dappToken balance", string(res.Payload))
+	}
+
+	res = invokeAs(stub, bob, "txList", "listTokens")
+	tokens := []model.ERC20Metadata{}
+	json.Unmarshal(res.Payload, &tokens)
+	if len(tokens) != 2 {
+		t.Fatal("unexpected token list", string(res.Payload))
+	}
+	// MINTER and BURNER are granted per token
+	res = invokeAs(stub, owner, "txGrant", "grantRole", model.MinterRole, bob.address)
+	if res.Status == shim.OK {
+		t.Fatal("MINTER without token must be rejected")
+	}
+
+	res = invokeAs(stub, owner, "txGrant", "grantRole", model.PauserRole, bob.address, "otherToken")
+	if res.Status == shim.OK {
+		t.Fatal("PAUSER of a token must be rejected")
+	}
+
+	// owner of a new token is its first MINTER and BURNER
+	for _, role := range model.TokenRoles {
+		res = invokeAs(stub, bob, "txHasRole", "hasRole", role, bob.address, "otherToken")
+		if string(res.Payload) != "true" {
+			t.Fatal("owner of otherToken must be "+role, res.Message, string(res.Payload))
+		}
+	}
+
+	res = invokeAs(stub, bob, "txMint", "mint", "otherToken", bob.address, "10")
+	if res.Status != shim.OK {
+		t.Fatal("mint of otherToken failed", res.Message)
+	}
+
+	res = invokeAs(stub, bob, "txMint", "mint", initTokenName, bob.address, "10")
+	if res.Status != 403 {
+		t.Fatal("MINTER of otherToken must not mint dappToken", res.Status)
+	}
+
+	res = invokeAs(stub, bob, "txMembers", "getRoleMembers", model.MinterRole, initTokenName)
+	if string(res.Payload) != `["`+owner.address+`"]` {
+		t.Fatal("owner must be the only minter of dappToken", string(res.Payload))
+	}
+}
+
+func TestBigAmounts(t *testing.T) {
+	stub, owner := configuration(t)
+	bob := newIdentity(t, "Org2MSP", "bob")
+
+	res := invokeAs(stub, bob, "txDecimals", "decimals", initTokenName)
+	if string(res.Payload) != initDecimals {
+		t.Fatal("unexpected decimals", string(res.Payload))
+	}
+
+	// amounts beyond int64 are kept exactly
+	const huge = "100000000000000000000000000000000000000"
+	res = invokeAs(stub, owner, "txMint", "mint", initTokenName, bob.address, huge)
+	if res.Status != shim.OK {
+		t.Fatal("mint failed", res.Message)
+	}
+
+	res = invokeAs(stub, bob, "txBalance", "balanceOf", initTokenName, bob.address)
+	if string(res.Payload) != huge {
+		t.Fatal("unexpected balance", string(res.Payload))
+	}
+
+	// minting past 2^256-1 is an explicit overflow
+	res = invokeAs(stub, owner, "txMint", "mint", initTokenName, bob.address, util.MaxAmount.String())
+	if res.Status == shim.OK {
+		t.Fatal("mint over 2^256-1 must overflow")
+	}
+
+	// spending more than balance is an explicit underflow
+	res = invokeAs(stub, owner, "txTransfer", "transfer", initTokenName, owner.address, bob.address, huge)
+	if res.Status == shim.OK {
+		t.Fatal("transfer over balance must underflow")
+	}
+}
+
+func TestPause(t *testing.T) {
+	stub, owner := configuration(t)
+	bob := newIdentity(t, "Org2MSP", "bob")
+
+	res := invokeAs(stub, bob, "txPause", "pause")
+	if res.Status != 403 {
+		t.Fatal("pause by non pauser must be forbidden", res.Status)
+	}
+
+	res = invokeAs(stub, owner, "txPause", "pause")
+	if res.Status != shim.OK {
+		t.Fatal("pause failed", res.Message)
+	}
+
+	events := nextEvents(t, stub)
+	if events[0].Name != repository.PauseEventKey {
+		t.Fatal("unexpected event", events[0].Name)
+	}
+
+	res = invokeAs(stub, bob, "txPaused", "paused")
+	if string(res.Payload) != "true" {
+		t.Fatal("token must be paused", string(res.Payload))
+	}
+
+	res = invokeAs(stub, owner, "txTransfer", "transfer", initTokenName, owner.address, bob.address, "10")
+	if res.Status == shim.OK {
+		t.Fatal("transfer must be rejected while paused")
+	}
+
+	res = invokeAs(stub, owner, "txApprove", "approve", initTokenName, owner.address, bob.address, "10")
+	if res.Status == shim.OK {
+		t.Fatal("approve must be rejected while paused")
+	}
+
+	res = invokeAs(stub, owner, "txUnpause", "unpause")
+	if res.Status != shim.OK {
+		t.Fatal("unpause failed", res.Message)
+	}
+
+	res = invokeAs(stub, owner, "txTransfer", "transfer", initTokenName, owner.address, bob.address, "10")
+	if res.Status != shim.OK {
+		t.Fatal("transfer failed after unpause", res.Message)
+	}
+}
+
+func TestFreeze(t *testing.T) {
+	stub, owner := configuration(t)
+	bob := newIdentity(t, "Org2MSP", "bob")
+
+	res := invokeAs(stub, bob, "txFreeze", "freezeAccount", owner.address)
+	if res.Status != 403 {
+		t.Fatal("freezeAccount by non admin must be forbidden", res.Status)
+	}
+
+	invokeAs(stub, owner, "txFund", "transfer", initTokenName, owner.address, bob.address, "100")
+	invokeAs(stub, owner, "txGrant", "grantRole", model.BurnerRole, bob.address, initTokenName)
+	invokeAs(stub, bob, "txApprove", "approve", initTokenName, bob.address, owner.address, "50")
+	for len(stub.ChaincodeEventsChannel) > 0 {
+		<-stub.ChaincodeEventsChannel
+	}
+
+	res = invokeAs(stub, owner, "txFreeze", "freezeAccount", bob.address)
+	if res.Status != shim.OK {
+		t.Fatal("freezeAccount failed", res.Message)
+	}
+
+	events := nextEvents(t, stub)
+	freezeEvent, err := decode.Freeze(events[0])
+	if err != nil || events[0].Name != repository.FreezeEventKey || freezeEvent.Address != bob.address || freezeEvent.Account != owner.address {
+		t.Fatal("unexpected freeze event", events, err)
+	}
+
+	res = invokeAs(stub, owner, "txIsFrozen", "isFrozen", bob.address)
+	if string(res.Payload) != "true" {
+		t.Fatal("bob must be frozen", string(res.Payload))
+	}
+
+	// frozen recipient & spender are rejected
+	res = invokeAs(stub, owner, "txTransfer", "transfer", initTokenName, owner.address, bob.address, "10")
+	if res.Status == shim.OK {
+		t.Fatal("transfer to frozen account must be rejected")
+	}
+
+	res = invokeAs(stub, owner, "txApprove", "approve", initTokenName, owner.address, bob.address, "10")
+	if res.Status == shim.OK {
+		t.Fatal("approve to frozen spender must be rejected")
+	}
+
+	// frozen holder cannot burn, and its tokens cannot be burnt by spender
+	res = invokeAs(stub, bob, "txBurn", "burn", initTokenName, bob.address, "10")
+	if res.Status == shim.OK {
+		t.Fatal("burn by frozen account must be rejected")
+	}
+
+	res = invokeAs(stub, owner, "txBurnFrom", "burnFrom", initTokenName, bob.address, owner.address, "10")
+	if res.Status == shim.OK {
+		t.Fatal("burnFrom of frozen account must be rejected")
+	}
+
+	res = invokeAs(stub, owner, "txList", "listFrozen")
+	records := []model.FreezeRecord{}
+	json.Unmarshal(res.Payload, &records)
+	if len(records) != 1 || records[0].Address != bob.address || records[0].FrozenBy != owner.address {
+		t.Fatal("unexpected freeze records", string(res.Payload))
+	}
+
+	for len(stub.ChaincodeEventsChannel) > 0 {
+		<-stub.ChaincodeEventsChannel
+	}
+
+	res = invokeAs(stub, owner, "txUnfreeze", "unfreezeAccount", bob.address)
+	if res.Status != shim.OK {
+		t.Fatal("unfreezeAccount failed", res.Message)
+	}
+
+	events = nextEvents(t, stub)
+	freezeEvent, err = decode.Freeze(events[0])
+	if err != nil || events[0].Name != repository.UnfreezeEventKey || freezeEvent.Address != bob.address || freezeEvent.Account != owner.address {
+		t.Fatal("unexpected unfreeze event", events, err)
+	}
+
+	res = invokeAs(stub, owner, "txUnfreeze", "unfreezeAccount", bob.address)
+	if res.Status == shim.OK {
+		t.Fatal("unfreezing an account which is not frozen must fail")
+	}
+
+	res = invokeAs(stub, owner, "txTransfer", "transfer", initTokenName, owner.address, bob.address, "10")
+	if res.Status != shim.OK {
+		t.Fatal("transfer failed after unfreeze", res.Message)
+	}
+
+	res = invokeAs(stub, owner, "txBurnFrom", "burnFrom", initTokenName, bob.address, owner.address, "10")
+	if res.Status != shim.OK {
+		t.Fatal("burnFrom failed after unfreeze", res.Message)
+	}
+}
+
+// historyStub serves GetHistoryForKey which the mock stub does not implement
+type historyStub struct {
+	*shimtest.MockStub
+	history map[string][]*queryresult.KeyModification
+}
+
+func (stub *historyStub) GetHistoryForKey(key string) (shim.HistoryQueryIteratorInterface, error) {
+	return &historyIterator{modifications: stub.history[key]}, nil
+}
+
+type historyIterator struct {
+	modifications []*queryresult.KeyModification
+	index         int
+}
+
+func (it *historyIterator) HasNext() bool {
+	return it.index < len(it.modifications)
+}
+
+func (it *historyIterator) Next() (*queryresult.KeyModification, error) {
+	it.index++
+	return it.modifications[it.index-1], nil
+}
+
+func (it *historyIterator) Close() error {
+	return nil
+}
+
+func TestBalanceHistory(t *testing.T) {
+	stub := &historyStub{MockStub: shimtest.NewMockStub("erc20", NewChaincode())}
+	balanceKey, _ := repository.CreateBalanceKey(stub, initTokenName, initOwner)
+	stub.history = map[string][]*queryresult.KeyModification{
+		balanceKey: {
+			{TxId: "tx1", Value: []byte("100"), Timestamp: &timestamp.Timestamp{Seconds: 1}},
+			{TxId: "tx2", Value: []byte("70"), Timestamp: &timestamp.Timestamp{Seconds: 2}},
+			{TxId: "tx3", Value: []byte("90"), Timestamp: &timestamp.Timestamp{Seconds: 3}},
+		},
+	}
+
+	router := controller.NewContoller().Routes()
+	res := router.Handle(stub, "balanceHistory", []string{initTokenName, initOwner, "2", ""})
+	page := model.HistoryPage{}
+	json.Unmarshal(res.Payload, &page)
+	if len(page.Entries) != 2 || page.Entries[1].TxID != "tx2" || page.Bookmark != "2:tx2" {
+		t.Fatal("unexpected first page", string(res.Payload))
+	}
+
+	// history written between page requests does not shift the next page
+	stub.history[balanceKey] = append([]*queryresult.KeyModification{
+		{TxId: "tx4", Value: []byte("80"), Timestamp: &timestamp.Timestamp{Seconds: 4}},
+	}, stub.history[balanceKey]...)
+
+	res = router.Handle(stub, "balanceHistory", []string{initTokenName, initOwner, "2", "9:tx9"})
+	if res.Status == shim.OK {
+		t.Fatal("unknown bookmark must be rejected")
+	}
+
+	res = router.Handle(stub, "balanceHistory", []string{initTokenName, initOwner, "2", page.Bookmark})
+	page = model.HistoryPage{}
+	json.Unmarshal(res.Payload, &page)
+	if len(page.Entries) != 1 || page.Entries[0].Value != "90" || page.Bookmark != "" {
+		t.Fatal("unexpected last page", string(res.Payload))
+	}
+}
+
+// paginationStub serves paginated queries which the mock stub does not implement
+type paginationStub struct {
+	*shimtest.MockStub
+}
+
+func (stub *paginationStub) GetStateByPartialCompositeKeyWithPagination(objectType string, keys []string,
+	pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *sc.QueryResponseMetadata, error) {
+	iterator, err := stub.GetStateByPartialCompositeKey(objectType, keys)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer iterator.Close()
+
+	// bookmark is the first key of the next page
+	page := &kvIterator{}
+	metadata := &sc.QueryResponseMetadata{}
+	for iterator.HasNext() {
+		kv, _ := iterator.Next()
+		if kv.GetKey() < bookmark {
+			continue
+		}
+		if int32(len(page.kvs)) == pageSize {
+			metadata.Bookmark = kv.GetKey()
+			break
+		}
+		page.kvs = append(page.kvs, kv)
+	}
+	metadata.FetchedRecordsCount = int32(len(page.kvs))
+
+	return page, metadata, nil
+}
+
+type kvIterator struct {
+	kvs   []*queryresult.KV
+	index int
+}
+
+func (it *kvIterator) HasNext() bool {
+	return it.index < len(it.kvs)
+}
+
+func (it *kvIterator) Next() (*queryresult.KV, error) {
+	it.index++
+	return it.kvs[it.index-1], nil
+}
+
+func (it *kvIterator) Close() error {
+	return nil
+}
+
+func TestHolders(t *testing.T) {
+	mockStub, owner := configuration(t)
+	bob := newIdentity(t, "Org2MSP", "bob")
+	carol := newIdentity(t, "Org2MSP", "carol")
+
+	// bob ends with zero balance, carol keeps tokens
+	invokeAs(mockStub, owner, "txTransfer", "transfer", initTokenName, owner.address, bob.address, "10")
+	invokeAs(mockStub, bob, "txTransfer", "transfer", initTokenName, bob.address, owner.address, "10")
+	invokeAs(mockStub, owner, "txTransfer", "transfer", initTokenName, owner.address, carol.address, "20")
+	invokeAs(mockStub, owner, "txApprove", "approve", initTokenName, owner.address, bob.address, "5")
+	invokeAs(mockStub, owner, "txApprove", "approve", initTokenName, owner.address, carol.address, "6")
+
+	stub := &paginationStub{MockStub: mockStub}
+	router := controller.NewContoller().Routes()
+
+	holders := map[string]string{}
+	bookmark := ""
+	for pages := 0; pages == 0 || bookmark != ""; pages++ {
+		res := router.Handle(stub, "holders", []string{initTokenName, "1", bookmark})
+		page := model.HolderPage{}
+		json.Unmarshal(res.Payload, &page)
+		for _, holder := range page.Holders {
+			holders[holder.Address] = holder.Balance
+		}
+		bookmark = page.Metadata.Bookmark
+		if pages > 3 {
+			t.Fatal("too many pages")
+		}
+	}
+
+	if len(holders) != 2 || holders[carol.address] != "20" || holders[owner.address] != strconv.Itoa(initAmount-20) {
+		t.Fatal("unexpected holders", holders)
+	}
+
+	res := router.Handle(stub, "approvalListWithPagination", []string{initTokenName, owner.address, "1", ""})
+	page := model.ApprovalPage{}
+	json.Unmarshal(res.Payload, &page)
+	if len(page.Approvals) != 1 || page.Metadata.FetchedRecordsCount != 1 || page.Metadata.Bookmark == "" {
+		t.Fatal("unexpected approval page", string(res.Payload))
+	}
+}
+
+func TestCap(t *testing.T) {
+	stub, owner := configuration(t)
+
+	// cap below total supply is rejected
+	res := invokeAs(stub, owner, "txSetCap", "setCap", initTokenName, strconv.Itoa(initAmount-1))
+	if res.Status == shim.OK {
+		t.Fatal("cap below total supply must be rejected")
+	}
+
+	res = invokeAs(stub, owner, "txSetCap", "setCap", initTokenName, strconv.Itoa(initAmount+100))
+	if res.Status != shim.OK {
+		t.Fatal("setCap failed", res.Message)
+	}
+
+	// cap is set only once
+	res = invokeAs(stub, owner, "txSetCap", "setCap", initTokenName, strconv.Itoa(initAmount+1000))
+	if res.Status == shim.OK {
+		t.Fatal("cap cannot be raised")
+	}
+
+	res = invokeAs(stub, owner, "txMint", "mint", initTokenName, owner.address, "101")
+	if res.Status == shim.OK {
+		t.Fatal("mint over cap must be rejected")
+	}
+
+	res = invokeAs(stub, owner, "txMint", "mint", initTokenName, owner.address, "60")
+	if res.Status != shim.OK {
+		t.Fatal("mint under cap failed", res.Message)
+	}
+
+	res = invokeAs(stub, owner, "txCap", "cap", initTokenName)
+	capInfo := model.CapInfo{}
+	json.Unmarshal(res.Payload, &capInfo)
+	if capInfo.Remaining != "40" || capInfo.Cap != strconv.Itoa(initAmount+100) {
+		t.Fatal("unexpected cap info", string(res.Payload))
+	}
+
+	// a corrupt cap refuses mint instead of leaving the token uncapped
+	metadata, _ := repository.GetERC20Metadata(stub, initTokenName)
+	metadata.Cap = "corrupt"
+	stub.MockTransactionStart("txCorrupt")
+	repository.SaveERC20Metadata(stub, metadata)
+	stub.MockTransactionEnd("txCorrupt")
+
+	res = invokeAs(stub, owner, "txMint", "mint", initTokenName, owner.address, "1")
+	if res.Status == shim.OK {
+		t.Fatal("mint with corrupt cap must be refused")
+	}
+}
+
+func TestPermit(t *testing.T) {
+	stub, owner := configuration(t)
+	bob := newIdentity(t, "Org2MSP", "bob")
+
+	res := invokeAs(stub, owner, "txRegister", "registerSigningKey", owner.address)
+	if res.Status != shim.OK {
+		t.Fatal("registerSigningKey failed", res.Message)
+	}
+
+	deadline := strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10)
+	digest := signature.PermitDigest(stub.GetChannelID(), stub.Name, initTokenName, owner.address, bob.address, "70", "0", deadline)
+	signatureBytes, _ := ecdsa.SignASN1(rand.Reader, owner.key, digest)
+	signatureHex := hex.EncodeToString(signatureBytes)
+
+	// bob relays owner's permit
+	res = invokeAs(stub, bob, "txPermit", "permit", initTokenName, owner.address, bob.address, "70", "0", deadline, signatureHex)
+	if res.Status != shim.OK {
+		t.Fatal("permit failed", res.Message)
+	}
+
+	allowance, _ := repository.GetAllowance(stub, initTokenName, owner.address, bob.address)
+	if allowance.Int64() != 70 {
+		t.Fatal("unexpected allowance", allowance)
+	}
+
+	// the same signature cannot be replayed
+	res = invokeAs(stub, bob, "txPermit", "permit", initTokenName, owner.address, bob.address, "70", "0", deadline, signatureHex)
+	if res.Status == shim.OK {
+		t.Fatal("permit replay must be rejected")
+	}
+
+	// signature does not cover a different value
+	res = invokeAs(stub, bob, "txPermit", "permit", initTokenName, owner.address, bob.address, "700", "1", deadline, signatureHex)
+	if res.Status != 403 {
+		t.Fatal("tampered permit must be forbidden", res.Status)
+	}
+
+	// permit signed for another chaincode of the channel is rejected
+	digest = signature.PermitDigest(stub.GetChannelID(), "otherErc20", initTokenName, owner.address, bob.address, "70", "1", deadline)
+	signatureBytes, _ = ecdsa.SignASN1(rand.Reader, owner.key, digest)
+	res = invokeAs(stub, bob, "txPermit", "permit", initTokenName, owner.address, bob.address, "70", "1", deadline, hex.EncodeToString(signatureBytes))
+	if res.Status != 403 {
+		t.Fatal("permit of another chaincode must be forbidden", res.Status)
+	}
+
+	// expired permit is rejected
+	expired := strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10)
+	digest = signature.PermitDigest(stub.GetChannelID(), stub.Name, initTokenName, owner.address, bob.address, "70", "1", expired)
+	signatureBytes, _ = ecdsa.SignASN1(rand.Reader, owner.key, digest)
+	res = invokeAs(stub, bob, "txPermit", "permit", initTokenName, owner.address, bob.address, "70", "1", expired, hex.EncodeToString(signatureBytes))
+	if res.Status == shim.OK {
+		t.Fatal("expired permit must be rejected")
+	}
+
+	res = invokeAs(stub, bob, "txNonces", "nonces", owner.address)
+	if string(res.Payload) != "1" {
+		t.Fatal("unexpected nonce", string(res.Payload))
+	}
+}
+
+func TestExecuteSigned(t *testing.T) {
+	stub, owner := configuration(t)
+	relayer := newIdentity(t, "Org2MSP", "relayer")
+	bob := newIdentity(t, "Org2MSP", "bob")
+
+	// mobile user has a key but no enrollment certificate
+	mobileKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	publicKeyHex := signature.MarshalPublicKey(&mobileKey.PublicKey)
+	mobileAddress := identity.AddressOfKey(signature.P256, publicKeyHex)
+
+	sign := func(digest []byte) string {
+		signatureBytes, _ := ecdsa.SignASN1(rand.Reader, mobileKey, digest)
+		return hex.EncodeToString(signatureBytes)
+	}
+
+	bindSignature := sign(signature.BindDigest(stub.GetChannelID(), stub.Name, mobileAddress, signature.P256, publicKeyHex))
+	res := invokeAs(stub, relayer, "txBind", "bindSigningKey", mobileAddress, signature.P256, publicKeyHex, bindSignature)
+	if res.Status != shim.OK {
+		t.Fatal("bindSigningKey failed", res.Message)
+	}
+
+	// the relayer cannot bind the key to its own address without proof
+	res = invokeAs(stub, relayer, "txBind", "bindSigningKey", owner.address, signature.P256, publicKeyHex, bindSignature)
+	if res.Status == shim.OK {
+		t.Fatal("binding a key to another account must be rejected")
+	}
+
+	invokeAs(stub, owner, "txTransfer", "transfer", initTokenName, owner.address, mobileAddress, "100")
+
+	expiry := strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10)
+	requestBytes, _ := json.Marshal(model.SignedRequest{
+		Signer:   mobileAddress,
+		Function: "transfer",
+		Args:     []string{initTokenName, mobileAddress, bob.address, "40"},
+		Nonce:    "0",
+		Expiry:   expiry,
+	})
+	request := string(requestBytes)
+	requestSignature := sign(signature.RequestDigest(stub.GetChannelID(), stub.Name, "0", request))
+
+	res = invokeAs(stub, relayer, "txExecute", "executeSigned", request, signature.P256, publicKeyHex, requestSignature)
+	if res.Status != shim.OK {
+		t.Fatal("executeSigned failed", res.Message)
+	}
+
+	bobBalance, _ := repository.GetBalance(stub, initTokenName, bob.address, true)
+	if bobBalance.Int64() != 40 {
+		t.Fatal("unexpected balance", bobBalance)
+	}
+
+	// replay is rejected by nonce
+	res = invokeAs(stub, relayer, "txExecute", "executeSigned", request, signature.P256, publicKeyHex, requestSignature)
+	if res.Status == shim.OK {
+		t.Fatal("replayed request must be rejected")
+	}
+
+	// signer cannot act for another address
+	requestBytes, _ = json.Marshal(model.SignedRequest{
+		Signer:   mobileAddress,
+		Function: "transfer",
+		Args:     []string{initTokenName, owner.address, bob.address, "40"},
+		Nonce:    "1",
+		Expiry:   expiry,
+	})
+	request = string(requestBytes)
+	res = invokeAs(stub, relayer, "txExecute", "executeSigned", request, signature.P256, publicKeyHex, sign(signature.RequestDigest(stub.GetChannelID(), stub.Name, "1", request)))
+	if res.Status == shim.OK {
+		t.Fatal("signed transfer from another account must be rejected")
+	}
+
+	// args of a signed request are checked like the params of a routed fnc
+	requestBytes, _ = json.Marshal(model.SignedRequest{
+		Signer:   mobileAddress,
+		Function: "transfer",
+		Args:     []string{initTokenName, mobileAddress, bob.address},
+		Nonce:    "2",
+		Expiry:   expiry,
+	})
+	request = string(requestBytes)
+	res = invokeAs(stub, relayer, "txExecute", "executeSigned", request, signature.P256, publicKeyHex, sign(signature.RequestDigest(stub.GetChannelID(), stub.Name, "2", request)))
+	if res.Status == shim.OK || !strings.Contains(res.Message, "takes params") {
+		t.Fatal("signed request with missing args must be rejected", res.Message)
+	}
+}
+
+// clockStub fixes the transaction timestamp which the mock stub sets to now
+type clockStub struct {
+	*shimtest.MockStub
+	seconds int64
+}
+
+func (stub *clockStub) GetTxTimestamp() (*timestamp.Timestamp, error) {
+	return &timestamp.Timestamp{Seconds: stub.seconds}, nil
+}
+
+func TestHTLC(t *testing.T) {
+	stub, owner := configuration(t)
+	bob := newIdentity(t, "Org2MSP", "bob")
+	escrow := identity.EscrowAddress("htlc")
+
+	preimage := hex.EncodeToString([]byte("swap secret"))
+	hash := sha256.Sum256([]byte("swap secret"))
+	hashlock := hex.EncodeToString(hash[:])
+	timelock := time.Now().Add(time.Hour).Unix()
+
+	res := invokeAs(stub, owner, "txLock", "htlcLock", initTokenName, owner.address, bob.address, "300", hashlock, strconv.FormatInt(timelock, 10))
+	if res.Status != shim.OK {
+		t.Fatal("htlcLock failed", res.Message)
+	}
+	claimID := string(res.Payload)
+
+	escrowBalance, _ := repository.GetBalance(stub, initTokenName, escrow, true)
+	if escrowBalance.Int64() != 300 {
+		t.Fatal("unexpected escrow balance", escrowBalance)
+	}
+
+	res = invokeAs(stub, bob, "txLocks", "htlcLocks", bob.address)
+	locks := []model.HTLC{}
+	json.Unmarshal(res.Payload, &locks)
+	if len(locks) != 1 || locks[0].ID != claimID {
+		t.Fatal("unexpected open locks", string(res.Payload))
+	}
+
+	res = invokeAs(stub, bob, "txClaim", "htlcClaim", claimID, hex.EncodeToString([]byte("wrong secret")))
+	if res.Status != 403 {
+		t.Fatal("wrong preimage must be forbidden", res.Message)
+	}
+
+	// sender cannot refund before timelock
+	res = invokeAs(stub, owner, "txRefund", "htlcRefund", claimID)
+	if res.Status == shim.OK {
+		t.Fatal("refund before timelock must be rejected")
+	}
+
+	res = invokeAs(stub, bob, "txClaim", "htlcClaim", claimID, preimage)
+	if res.Status != shim.OK {
+		t.Fatal("htlcClaim failed", res.Message)
+	}
+
+	bobBalance, _ := repository.GetBalance(stub, initTokenName, bob.address, true)
+	if bobBalance.Int64() != 300 {
+		t.Fatal("unexpected recipient balance", bobBalance)
+	}
+
+	res = invokeAs(stub, bob, "txClaim", "htlcClaim", claimID, preimage)
+	if res.Status == shim.OK {
+		t.Fatal("claimed lock cannot be claimed twice")
+	}
+
+	res = invokeAs(stub, owner, "txLock", "htlcLock", initTokenName, owner.address, bob.address, "200", hashlock, strconv.FormatInt(timelock, 10))
+	refundID := string(res.Payload)
+
+	// after timelock the recipient cannot claim and the sender gets refund
+	router := controller.NewContoller().Routes()
+	expired := &clockStub{MockStub: stub, seconds: timelock}
+	stub.MockTransactionStart("txExpired")
+	res = router.Handle(expired, "htlcClaim", []string{refundID, preimage})
+	if res.Status == shim.OK {
+		t.Fatal("claim after timelock must be rejected")
+	}
+
+	res = router.Handle(expired, "htlcRefund", []string{refundID})
+	stub.MockTransactionEnd("txExpired")
+	if res.Status != shim.OK {
+		t.Fatal("htlcRefund failed", res.Message)
+	}
+
+	ownerBalance, _ := repository.GetBalance(stub, initTokenName, owner.address, true)
+	escrowBalance, _ = repository.GetBalance(stub, initTokenName, escrow, true)
+	if ownerBalance.Int64() != initAmount-300 || escrowBalance.Sign() != 0 {
+		t.Fatal("unexpected balances after refund", ownerBalance, escrowBalance)
+	}
+
+	res = invokeAs(stub, owner, "txLocks", "htlcLocks", owner.address)
+	json.Unmarshal(res.Payload, &locks)
+	if len(locks) != 0 {
+		t.Fatal("closed locks must leave the index", string(res.Payload))
+	}
+}
+
+func TestVesting(t *testing.T) {
+	stub, owner := configuration(t)
+	bob := newIdentity(t, "Org2MSP", "bob")
+	router := controller.NewContoller().Routes()
+
+	// 1000 tokens vest over 1000 seconds after a 100 seconds cliff
+	start := int64(1000000)
+	stub.Creator = owner.creator
+	stub.MockTransactionStart("txVesting")
+	res := router.Handle(&clockStub{MockStub: stub, seconds: start}, "createVesting", []string{initTokenName, owner.address, bob.address, "1000", strconv.FormatInt(start, 10), "100", "1000", "true"})
+	stub.MockTransactionEnd("txVesting")
+	if res.Status != shim.OK {
+		t.Fatal("createVesting failed", res.Message)
+	}
+	id := string(res.Payload)
+
+	release := func(txID string, seconds int64) sc.Response {
+		stub.MockTransactionStart(txID)
+		defer stub.MockTransactionEnd(txID)
+		return router.Handle(&clockStub{MockStub: stub, seconds: seconds}, "releaseVested", []string{id})
+	}
+
+	res = release("txBeforeCliff", start+99)
+	if res.Status == shim.OK {
+		t.Fatal("nothing is releasable before cliff")
+	}
+
+	res = release("txRelease", start+250)
+	if res.Status != shim.OK || string(res.Payload) != "250" {
+		t.Fatal("unexpected release", res.Status, res.Message, string(res.Payload))
+	}
+
+	// revoke returns the unvested part to grantor
+	stub.Creator = bob.creator
+	stub.MockTransactionStart("txForgedRevoke")
+	res = router.Handle(&clockStub{MockStub: stub, seconds: start + 400}, "revokeVesting", []string{id})
+	stub.MockTransactionEnd("txForgedRevoke")
+	if res.Status == shim.OK {
+		t.Fatal("only grantor can revoke")
+	}
+
+	stub.Creator = owner.creator
+	stub.MockTransactionStart("txRevoke")
+	res = router.Handle(&clockStub{MockStub: stub, seconds: start + 400}, "revokeVesting", []string{id})
+	stub.MockTransactionEnd("txRevoke")
+	if res.Status != shim.OK || string(res.Payload) != "600" {
+		t.Fatal("unexpected revoke", res.Status, res.Message, string(res.Payload))
+	}
+
+	// vested part stays releasable after revoke
+	res = release("txReleaseRevoked", start+2000)
+	if res.Status != shim.OK || string(res.Payload) != "150" {
+		t.Fatal("unexpected release after revoke", res.Status, res.Message, string(res.Payload))
+	}
+
+	ownerBalance, _ := repository.GetBalance(stub, initTokenName, owner.address, true)
+	bobBalance, _ := repository.GetBalance(stub, initTokenName, bob.address, true)
+	escrowBalance, _ := repository.GetBalance(stub, initTokenName, identity.EscrowAddress("vesting"), true)
+	if ownerBalance.Int64() != initAmount-400 || bobBalance.Int64() != 400 || escrowBalance.Sign() != 0 {
+		t.Fatal("unexpected balances", ownerBalance, bobBalance, escrowBalance)
+	}
+
+	res = invokeAs(stub, bob, "txInfo", "vestingInfo", id)
+	info := model.VestingInfo{}
+	json.Unmarshal(res.Payload, &info)
+	if !info.Revoked || info.Released != "400" || info.Releasable != "0" {
+		t.Fatal("unexpected vesting info", string(res.Payload))
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	stub, owner := configuration(t)
+	bob := newIdentity(t, "Org2MSP", "bob")
+
+	res := invokeAs(stub, bob, "txSnapshot", "snapshot", initTokenName)
+	if res.Status != 403 {
+		t.Fatal("snapshot without ADMIN role must be forbidden", res.Status)
+	}
+
+	res = invokeAs(stub, owner, "txSnapshot", "snapshot", initTokenName)
+	if res.Status != shim.OK || string(res.Payload) != "1" {
+		t.Fatal("unexpected snapshot", res.Message, string(res.Payload))
+	}
+
+	invokeAs(stub, owner, "txTransfer1", "transfer", initTokenName, owner.address, bob.address, "300")
+	invokeAs(stub, owner, "txTransfer2", "transfer", initTokenName, owner.address, bob.address, "200")
+
+	res = invokeAs(stub, owner, "txSnapshot", "snapshot", initTokenName)
+	if string(res.Payload) != "2" {
+		t.Fatal("snapshot id must increase", string(res.Payload))
+	}
+
+	invokeAs(stub, owner, "txMint", "mint", initTokenName, bob.address, "1000")
+
+	expected := []struct {
+		id, owner, bob, totalSupply string
+	}{
+		{"1", strconv.Itoa(initAmount), "0", strconv.Itoa(initAmount)},
+		{"2", strconv.Itoa(initAmount - 500), "500", strconv.Itoa(initAmount)},
+	}
+	for _, snapshot := range expected {
+		ownerRes := invokeAs(stub, owner, "txQuery", "balanceOfAt", initTokenName, owner.address, snapshot.id)
+		bobRes := invokeAs(stub, owner, "txQuery", "balanceOfAt", initTokenName, bob.address, snapshot.id)
+		supplyRes := invokeAs(stub, owner, "txQuery", "totalSupplyAt", initTokenName, snapshot.id)
+		if string(ownerRes.Payload) != snapshot.owner || string(bobRes.Payload) != snapshot.bob || string(supplyRes.Payload) != snapshot.totalSupply {
+			t.Fatal("unexpected snapshot values", snapshot.id, string(ownerRes.Payload), string(bobRes.Payload), string(supplyRes.Payload))
+		}
+	}
+
+	res = invokeAs(stub, owner, "txQuery", "balanceOfAt", initTokenName, owner.address, "3")
+	if res.Status == shim.OK {
+		t.Fatal("future snapshot must be rejected")
+	}
+}
+
+func TestDividend(t *testing.T) {
+	stub, owner := configuration(t)
+	bob := newIdentity(t, "Org2MSP", "bob")
+
+	invokeAs(stub, owner, "txTransfer", "transfer", initTokenName, owner.address, bob.address, strconv.Itoa(initAmount/4))
+	invokeAs(stub, owner, "txSnapshot", "snapshot", initTokenName)
+
+	res := invokeAs(stub, bob, "txDistribute", "createDistribution", initTokenName, "1", initTokenName, bob.address, "7")
+	if res.Status != 403 {
+		t.Fatal("distribution without ADMIN role must be forbidden", res.Status)
+	}
+
+	// 7 tokens for 1/4 and 3/4 of the supply leave 1 token of dust
+	res = invokeAs(stub, owner, "txDistribute", "createDistribution", initTokenName, "1", initTokenName, owner.address, "7")
+	if res.Status != shim.OK {
+		t.Fatal("createDistribution failed", res.Message)
+	}
+	id := string(res.Payload)
+
+	res = invokeAs(stub, bob, "txUnclaimed", "unclaimedDividend", id, bob.address)
+	if string(res.Payload) != "1" {
+		t.Fatal("unexpected unclaimed dividend", string(res.Payload))
+	}
+
+	res = invokeAs(stub, owner, "txClaim", "claimDividend", id, bob.address)
+	if res.Status == shim.OK {
+		t.Fatal("only holder can claim the share")
+	}
+
+	distributionKey, _ := stub.CreateCompositeKey(repository.DistributionPrefix, []string{id})
+	distributionBytes := stub.State[distributionKey]
+
+	res = invokeAs(stub, bob, "txClaim", "claimDividend", id, bob.address)
+	if res.Status != shim.OK || string(res.Payload) != "1" {
+		t.Fatal("unexpected claim", res.Message, string(res.Payload))
+	}
+
+	// claims of different holders do not write a shared key
+	if string(stub.State[distributionKey]) != string(distributionBytes) {
+		t.Fatal("claim must not write the distribution")
+	}
+
+	res = invokeAs(stub, bob, "txClaim", "claimDividend", id, bob.address)
+	if res.Status == shim.OK {
+		t.Fatal("share is claimed once")
+	}
+
+	res = invokeAs(stub, owner, "txClaim", "claimDividend", id, owner.address)
+	if res.Status != shim.OK || string(res.Payload) != "5" {
+		t.Fatal("unexpected claim", res.Message, string(res.Payload))
+	}
+
+	res = invokeAs(stub, owner, "txInfo", "distributionInfo", id)
+	info := model.DistributionInfo{}
+	json.Unmarshal(res.Payload, &info)
+	if info.Claimed != "6" || info.Unclaimed != "0" || info.Dust != "1" {
+		t.Fatal("unexpected distribution info", string(res.Payload))
+	}
+
+	escrowBalance, _ := repository.GetBalance(stub, initTokenName, identity.EscrowAddress("dividend"), true)
+	if escrowBalance.Int64() != 1 {
+		t.Fatal("dust stays in escrow", escrowBalance)
+	}
+
+	// payer reclaims the dust
+	res = invokeAs(stub, bob, "txReclaim", "reclaimDividend", id)
+	if res.Status == shim.OK {
+		t.Fatal("only payer can reclaim")
+	}
+
+	res = invokeAs(stub, owner, "txReclaim", "reclaimDividend", id)
+	if res.Status != shim.OK || string(res.Payload) != "1" {
+		t.Fatal("unexpected reclaim", res.Message, string(res.Payload))
+	}
+
+	res = invokeAs(stub, owner, "txReclaim", "reclaimDividend", id)
+	if res.Status == shim.OK {
+		t.Fatal("nothing is left to reclaim")
+	}
+
+	// the share of an escrow account is reclaimed by payer
+	invokeAs(stub, owner, "txLock", "htlcLock", initTokenName, owner.address, bob.address, "200", hex.EncodeToString(make([]byte, 32)), strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10))
+	invokeAs(stub, owner, "txSnapshot2", "snapshot", initTokenName)
+
+	res = invokeAs(stub, owner, "txDistribute2", "createDistribution", initTokenName, "2", initTokenName, owner.address, "10000")
+	if res.Status != shim.OK {
+		t.Fatal("createDistribution failed", res.Message)
+	}
+	id = string(res.Payload)
+
+	res = invokeAs(stub, owner, "txReclaim2", "reclaimDividend", id)
+	if res.Status != shim.OK || string(res.Payload) != "2" {
+		t.Fatal("unexpected reclaim", res.Message, string(res.Payload))
+	}
+
+	res = invokeAs(stub, owner, "txInfo2", "distributionInfo", id)
+	info = model.DistributionInfo{}
+	json.Unmarshal(res.Payload, &info)
+	if info.Reclaimed != "2" || info.Unclaimed != "9998" || info.Dust != "0" {
+		t.Fatal("unexpected distribution info", string(res.Payload))
+	}
+
+	deadline := time.Now().Add(time.Hour).Unix()
+	res = invokeAs(stub, owner, "txDistribute3", "createDistribution", initTokenName, "2", initTokenName, owner.address, "100", strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10))
+	if res.Status == shim.OK {
+		t.Fatal("deadline in the past must be refused")
+	}
+
+	res = invokeAs(stub, owner, "txDistribute3", "createDistribution", initTokenName, "2", initTokenName, owner.address, "100", strconv.FormatInt(deadline, 10))
+	if res.Status != shim.OK {
+		t.Fatal("createDistribution failed", res.Message)
+	}
+	id = string(res.Payload)
+
+	res = invokeAs(stub, bob, "txClaim3", "claimDividend", id, bob.address)
+	if res.Status != shim.OK {
+		t.Fatal("claim before deadline failed", res.Message)
+	}
+	claimed, _ := new(big.Int).SetString(string(res.Payload), 10)
+
+	router := controller.NewContoller().Routes()
+	afterDeadline := func(txID string, caller *testIdentity, fnc string, params ...string) sc.Response {
+		stub.Creator = caller.creator
+		stub.MockTransactionStart(txID)
+		defer stub.MockTransactionEnd(txID)
+		return router.Handle(&clockStub{MockStub: stub, seconds: deadline}, fnc, params)
+	}
+
+	res = afterDeadline("txClaim4", owner, "claimDividend", id, owner.address)
+	if res.Status == shim.OK {
+		t.Fatal("claim after deadline must be refused")
+	}
+
+	res = afterDeadline("txUnclaimed4", owner, "unclaimedDividend", id, owner.address)
+	if string(res.Payload) != "0" {
+		t.Fatal("nothing is claimable after deadline", string(res.Payload))
+	}
+
+	// payer reclaims every share which is not claimed
+	res = afterDeadline("txReclaim4", owner, "reclaimDividend", id)
+	reclaimed, _ := new(big.Int).SetString(string(res.Payload), 10)
+	if res.Status != shim.OK || new(big.Int).Add(claimed, reclaimed).Int64() != 100 {
+		t.Fatal("unexpected reclaim", res.Message, string(res.Payload))
+	}
+
+	res = invokeAs(stub, owner, "txInfo4", "distributionInfo", id)
+	info = model.DistributionInfo{}
+	json.Unmarshal(res.Payload, &info)
+	if info.Unclaimed != "0" || info.Dust != "0" {
+		t.Fatal("unexpected distribution info", string(res.Payload))
+	}
+
+	// share of a holder without Fabric identity is reclaimed once payer sets a deadline
+	keyHolder := identity.AddressOfKey("P-256", "04"+strings.Repeat("ab", 64))
+	invokeAs(stub, owner, "txTransfer6", "transfer", initTokenName, owner.address, keyHolder, "100")
+	res = invokeAs(stub, owner, "txSnapshot6", "snapshot", initTokenName)
+	snapshotID := string(res.Payload)
+
+	res = invokeAs(stub, owner, "txDistribute6", "createDistribution", initTokenName, snapshotID, initTokenName, owner.address, "1000")
+	if res.Status != shim.OK {
+		t.Fatal("createDistribution failed", res.Message)
+	}
+	id = string(res.Payload)
+
+	invokeAs(stub, owner, "txClaim6", "claimDividend", id, owner.address)
+	invokeAs(stub, bob, "txClaim6", "claimDividend", id, bob.address)
+
+	res = invokeAs(stub, owner, "txInfo6", "distributionInfo", id)
+	info = model.DistributionInfo{}
+	json.Unmarshal(res.Payload, &info)
+	if info.Unclaimed == "0" {
+		t.Fatal("share of key holder must stay unclaimed", string(res.Payload))
+	}
+
+	soon := strconv.FormatInt(time.Now().Unix()+model.DividendNoticePeriod/2, 10)
+	res = invokeAs(stub, owner, "txDeadline6", "setDividendDeadline", id, soon)
+	if res.Status == shim.OK {
+		t.Fatal("deadline within the notice period must be refused")
+	}
+
+	deadline = time.Now().Unix() + 2*model.DividendNoticePeriod
+	res = invokeAs(stub, bob, "txDeadline6", "setDividendDeadline", id, strconv.FormatInt(deadline, 10))
+	if res.Status == shim.OK {
+		t.Fatal("only payer can set the deadline")
+	}
+
+	res = invokeAs(stub, owner, "txDeadline6", "setDividendDeadline", id, strconv.FormatInt(deadline, 10))
+	if res.Status != shim.OK {
+		t.Fatal("setDividendDeadline failed", res.Message)
+	}
+
+	res = invokeAs(stub, owner, "txDeadline6", "setDividendDeadline", id, strconv.FormatInt(deadline+1, 10))
+	if res.Status == shim.OK {
+		t.Fatal("deadline is set once")
+	}
+
+	// unclaimed shares and dust are reclaimed together
+	unclaimed, _ := new(big.Int).SetString(info.Unclaimed, 10)
+	dust, _ := new(big.Int).SetString(info.Dust, 10)
+	res = afterDeadline("txReclaim6", owner, "reclaimDividend", id)
+	if res.Status != shim.OK || string(res.Payload) != new(big.Int).Add(unclaimed, dust).String() {
+		t.Fatal("unexpected reclaim", res.Message, string(res.Payload), info.Unclaimed, info.Dust)
+	}
+
+	// payout token of another chaincode is refused, no account held there can be withdrawn safely
+	payout := shimtest.NewMockStub("payout", &calledChaincode{Chaincode: NewChaincode(), caller: stub})
+	payout.Creator = owner.creator
+	payout.MockInit("1", [][]byte{[]byte("Init"), []byte("payoutToken"), []byte("pt"), []byte(owner.address), []byte("1000"), []byte("0")})
+	stub.MockPeerChaincode("payout", payout, "")
+
+	res = invokeAs(stub, owner, "txDistribute5", "createDistribution", initTokenName, "1", "payoutToken", owner.address, "100", "0", "payout")
+	if res.Status == shim.OK {
+		t.Fatal("payout chaincode must be refused")
+	}
+
+	// a chaincode in the middle of the call chain cannot pull tokens from the payout chaincode
+	middle := shimtest.NewMockStub("middle", &forwardChaincode{target: "payout", args: [][]byte{[]byte("chaincodeWithdraw"), []byte("payoutToken"), []byte(bob.address), []byte("100")}})
+	middle.MockPeerChaincode("payout", payout, "")
+	stub.MockPeerChaincode("middle", middle, "")
+
+	res = invokeAs(stub, bob, "txMiddle", "transferOtherToken", "middle", "payoutToken", bob.address, bob.address, "100")
+	if res.Status == shim.OK {
+		t.Fatal("withdraw through a chaincode in the middle must be refused")
+	}
+
+	bobBalance, _ := repository.GetBalance(payout, "payoutToken", bob.address, true)
+	ownerBalance, _ := repository.GetBalance(payout, "payoutToken", owner.address, true)
+	if bobBalance.Sign() != 0 || ownerBalance.Int64() != 1000 {
+		t.Fatal("payout chaincode balances must not change", bobBalance, ownerBalance)
+	}
+}
+
+func TestGovernance(t *testing.T) {
+	stub, owner := configuration(t)
+	bob := newIdentity(t, "Org2MSP", "bob")
+	router := controller.NewContoller().Routes()
+
+	invokeAs(stub, owner, "txTransfer", "transfer", initTokenName, owner.address, bob.address, strconv.Itoa(initAmount/4))
+	invokeAs(stub, owner, "txCreate", "createToken", "otherToken", "ot", owner.address, "1000", "0")
+
+	// proposer must hold the proposal threshold
+	carol := newIdentity(t, "Org2MSP", "carol")
+	res := invokeAs(stub, carol, "txPropose", "propose", initTokenName, carol.address, "snapshot spam", `[{"function":"mint","args":["dappToken","`+carol.address+`","1"]}]`, "3600")
+	if res.Status != 403 {
+		t.Fatal("propose below proposal threshold must be forbidden", res.Status)
+	}
+
+	// token actions are scoped to the token of the proposal
+	res = invokeAs(stub, owner, "txPropose", "propose", "otherToken", owner.address, "mint dappToken", `[{"function":"mint","args":["dappToken","`+owner.address+`","1"]}]`, "3600")
+	if res.Status == shim.OK {
+		t.Fatal("mint of another token must be rejected")
+	}
+
+	res = invokeAs(stub, owner, "txPropose", "propose", "otherToken", owner.address, "config", `[{"function":"setGovernanceConfig","args":["dappToken","0","0","0"]}]`, "3600")
+	if res.Status == shim.OK {
+		t.Fatal("config of another token must be rejected")
+	}
+
+	// chaincode wide actions need the governance token
+	res = invokeAs(stub, owner, "txPropose", "propose", initTokenName, owner.address, "admin", `[{"function":"grantRole","args":["ADMIN","`+owner.address+`"]}]`, "3600")
+	if res.Status == shim.OK {
+		t.Fatal("role change without governance token must be rejected")
+	}
+
+	res = invokeAs(stub, bob, "txGovernanceToken", "setGovernanceToken", initTokenName)
+	if res.Status != 403 {
+		t.Fatal("setGovernanceToken without ADMIN role must be forbidden", res.Status)
+	}
+
+	res = invokeAs(stub, owner, "txGovernanceToken", "setGovernanceToken", initTokenName)
+	if res.Status != shim.OK {
+		t.Fatal("setGovernanceToken failed", res.Message)
+	}
+
+	res = invokeAs(stub, owner, "txPropose", "propose", "otherToken", owner.address, "admin", `[{"function":"grantRole","args":["ADMIN","`+owner.address+`"]}]`, "3600")
+	if res.Status == shim.OK {
+		t.Fatal("role change by other token must be rejected")
+	}
+
+	res = invokeAs(stub, owner, "txPropose", "propose", initTokenName, owner.address, "pause and unpause", `[{"function":"pause","args":[]},{"function":"unpause","args":[]}]`, "3600")
+	if res.Status == shim.OK {
+		t.Fatal("actions changing the same state must be rejected")
+	}
+
+	// mint reads the pause flag, which the proposal would not see changed
+	res = invokeAs(stub, owner, "txPropose", "propose", initTokenName, owner.address, "unpause and mint", `[{"function":"unpause","args":[]},{"function":"mint","args":["dappToken","`+bob.address+`","1"]}]`, "3600")
+	if res.Status == shim.OK {
+		t.Fatal("mint with pause change must be rejected")
+	}
+
+	res = invokeAs(stub, owner, "txPropose", "propose", initTokenName, owner.address, "burn", `[{"function":"burn","args":["dappToken","x","1"]}]`, "3600")
+	if res.Status == shim.OK {
+		t.Fatal("non admin function must be rejected")
+	}
+
+	res = invokeAs(stub, owner, "txPropose", "propose", initTokenName, owner.address, "minter", `[{"function":"grantRole","args":["MINTER","`+bob.address+`","otherToken"]}]`, "3600")
+	if res.Status == shim.OK {
+		t.Fatal("role of another token must be rejected")
+	}
+
+	actions := `[{"function":"mint","args":["dappToken","` + bob.address + `","500"]},{"function":"grantRole","args":["PAUSER","` + bob.address + `"]},{"function":"grantRole","args":["MINTER","` + bob.address + `","dappToken"]}]`
+	res = invokeAs(stub, owner, "txPropose", "propose", initTokenName, owner.address, "reward bob", actions, "3600")
+	if res.Status != shim.OK {
+		t.Fatal("propose failed", res.Message)
+	}
+	id := string(res.Payload)
+
+	// balances moved after proposal do not change voting power
+	invokeAs(stub, owner, "txTransfer2", "transfer", initTokenName, owner.address, bob.address, strconv.Itoa(initAmount/2))
+
+	res = invokeAs(stub, owner, "txVote", "castVote", id, owner.address, model.VoteFor)
+	if res.Status != shim.OK || string(res.Payload) != strconv.Itoa(initAmount*3/4) {
+		t.Fatal("unexpected vote", res.Message, string(res.Payload))
+	}
+
+	res = invokeAs(stub, bob, "txVote", "castVote", id, bob.address, model.VoteAgainst)
+	if res.Status != shim.OK || string(res.Payload) != strconv.Itoa(initAmount/4) {
+		t.Fatal("unexpected vote", res.Message, string(res.Payload))
+	}
+
+	res = invokeAs(stub, bob, "txVote", "castVote", id, bob.address, model.VoteFor)
+	if res.Status == shim.OK {
+		t.Fatal("voter votes once")
+	}
+
+	res = invokeAs(stub, bob, "txTally", "tallyProposal", id)
+	if res.Status == shim.OK {
+		t.Fatal("tally before end must be rejected")
+	}
+
+	proposal, _ := repository.GetProposal(stub, id)
+	stub.MockTransactionStart("txTally")
+	res = router.Handle(&clockStub{MockStub: stub, seconds: proposal.End}, "tallyProposal", []string{id})
+	stub.MockTransactionEnd("txTally")
+	if res.Status != shim.OK || string(res.Payload) != model.ProposalSucceeded {
+		t.Fatal("unexpected tally", res.Message, string(res.Payload))
+	}
+
+	res = invokeAs(stub, bob, "txExecute", "executeProposal", id)
+	if res.Status != shim.OK {
+		t.Fatal("executeProposal failed", res.Message)
+	}
+
+	totalSupply, _ := repository.GetERC20TotalSupply(stub, initTokenName)
+	isPauser, _ := repository.HasRole(stub, "", model.PauserRole, bob.address)
+	isMinter, _ := repository.HasRole(stub, initTokenName, model.MinterRole, bob.address)
+	if totalSupply.Int64() != initAmount+500 || !isPauser || !isMinter {
+		t.Fatal("actions are not executed", totalSupply, isPauser, isMinter)
+	}
+
+	res = invokeAs(stub, bob, "txExecute", "executeProposal", id)
+	if res.Status == shim.OK {
+		t.Fatal("proposal is executed once")
+	}
+
+	// minting by proposal respects the freeze of the recipient
+	actions = `[{"function":"mint","args":["dappToken","` + carol.address + `","500"]}]`
+	res = invokeAs(stub, owner, "txPropose2", "propose", initTokenName, owner.address, "reward carol", actions, "3600")
+	if res.Status != shim.OK {
+		t.Fatal("propose failed", res.Message)
+	}
+	id = string(res.Payload)
+
+	invokeAs(stub, owner, "txVote2", "castVote", id, owner.address, model.VoteFor)
+	invokeAs(stub, bob, "txVote2", "castVote", id, bob.address, model.VoteFor)
+
+	proposal, _ = repository.GetProposal(stub, id)
+
+	// a corrupt vote weight fails the tally instead of counting the vote as 0
+	votes, _ := repository.ListVotes(stub, id)
+	corrupt := votes[0]
+	corrupt.Weight = "corrupt"
+	stub.MockTransactionStart("txCorrupt")
+	repository.SaveVote(stub, id, &corrupt)
+	stub.MockTransactionEnd("txCorrupt")
+
+	stub.MockTransactionStart("txTally2")
+	res = router.Handle(&clockStub{MockStub: stub, seconds: proposal.End}, "tallyProposal", []string{id})
+	stub.MockTransactionEnd("txTally2")
+	if res.Status == shim.OK {
+		t.Fatal("tally with corrupt vote weight must fail")
+	}
+
+	stub.MockTransactionStart("txRestore")
+	repository.SaveVote(stub, id, &votes[0])
+	stub.MockTransactionEnd("txRestore")
+
+	stub.MockTransactionStart("txTally2")
+	res = router.Handle(&clockStub{MockStub: stub, seconds: proposal.End}, "tallyProposal", []string{id})
+	stub.MockTransactionEnd("txTally2")
+	if res.Status != shim.OK || string(res.Payload) != model.ProposalSucceeded {
+		t.Fatal("unexpected tally", res.Message, string(res.Payload))
+	}
+
+	invokeAs(stub, owner, "txFreeze", "freezeAccount", carol.address)
+	res = invokeAs(stub, bob, "txExecute2", "executeProposal", id)
+	if res.Status == shim.OK {
+		t.Fatal("mint to frozen account must be rejected")
+	}
+
+	invokeAs(stub, owner, "txUnfreeze", "unfreezeAccount", carol.address)
+	invokeAs(stub, owner, "txPause", "pause")
+	res = invokeAs(stub, bob, "txExecute2", "executeProposal", id)
+	if res.Status == shim.OK {
+		t.Fatal("mint while paused must be rejected")
+	}
+
+	invokeAs(stub, owner, "txUnpause", "unpause")
+	res = invokeAs(stub, bob, "txExecute2", "executeProposal", id)
+	if res.Status != shim.OK {
+		t.Fatal("executeProposal failed", res.Message)
+	}
+}
+
+func TestDelegation(t *testing.T) {
+	stub, owner := configuration(t)
+	bob := newIdentity(t, "Org2MSP", "bob")
+	carol := newIdentity(t, "Org2MSP", "carol")
+	router := controller.NewContoller().Routes()
+
+	// invoke at a fixed transaction timestamp
+	at := func(id *testIdentity, seconds int64, fnc string, params ...string) sc.Response {
+		stub.Creator = id.creator
+		stub.MockTransactionStart(fmt.Sprintf("tx%d", seconds))
+		defer stub.MockTransactionEnd(fmt.Sprintf("tx%d", seconds))
+		return router.Handle(&clockStub{MockStub: stub, seconds: seconds}, fnc, params)
+	}
+	votesAt := func(address string, seconds int64) string {
+		return string(at(owner, 1000, "getPastVotes", initTokenName, address, strconv.FormatInt(seconds, 10)).Payload)
+	}
+
+	// owner delegates to self, bob delegates to carol
+	at(owner, 100, "delegate", initTokenName, owner.address, owner.address)
+	at(bob, 100, "delegate", initTokenName, bob.address, carol.address)
+
+	res := at(owner, 200, "transfer", initTokenName, owner.address, bob.address, "300")
+	if res.Status != shim.OK {
+		t.Fatal("transfer failed", res.Message)
+	}
+
+	res = at(owner, 300, "burn", initTokenName, owner.address, "100")
+	if res.Status != shim.OK {
+		t.Fatal("burn failed", res.Message)
+	}
+
+	// bob moves his votes from carol to himself
+	at(bob, 400, "delegate", initTokenName, bob.address, bob.address)
+
+	expected := []struct {
+		address string
+		seconds int64
+		votes   string
+	}{
+		{owner.address, 99, "0"},
+		{owner.address, 100, strconv.Itoa(initAmount)},
+		{owner.address, 250, strconv.Itoa(initAmount - 300)},
+		{owner.address, 300, strconv.Itoa(initAmount - 400)},
+		{carol.address, 199, "0"},
+		{carol.address, 200, "300"},
+		{carol.address, 400, "0"},
+		{bob.address, 399, "0"},
+		{bob.address, 400, "300"},
+	}
+	for _, checkpoint := range expected {
+		if votes := votesAt(checkpoint.address, checkpoint.seconds); votes != checkpoint.votes {
+			t.Fatal("unexpected past votes", checkpoint.address, checkpoint.seconds, votes)
+		}
+	}
+
+	res = at(owner, 1000, "getPastVotes", initTokenName, owner.address, "1000")
+	if res.Status == shim.OK {
+		t.Fatal("votes of the current second must be rejected")
+	}
+
+	// a backdated transaction is inserted at its timestamp and added to the later checkpoints
+	res = at(owner, 250, "transfer", initTokenName, owner.address, bob.address, "50")
+	if res.Status != shim.OK {
+		t.Fatal("transfer failed", res.Message)
+	}
+
+	expected = []struct {
+		address string
+		seconds int64
+		votes   string
+	}{
+		{owner.address, 249, strconv.Itoa(initAmount - 300)},
+		{owner.address, 250, strconv.Itoa(initAmount - 350)},
+		{owner.address, 299, strconv.Itoa(initAmount - 350)},
+		{owner.address, 300, strconv.Itoa(initAmount - 450)},
+		{bob.address, 249, "0"},
+		{bob.address, 250, "50"},
+		{bob.address, 400, "350"},
+		{bob.address, 999, "350"},
+	}
+	for _, checkpoint := range expected {
+		if votes := votesAt(checkpoint.address, checkpoint.seconds); votes != checkpoint.votes {
+			t.Fatal("unexpected past votes after backdated transfer", checkpoint.address, checkpoint.seconds, votes)
+		}
+	}
+
+	// a future dated transaction does not pin the checkpoints of later transactions
+	at(owner, 5000, "transfer", initTokenName, owner.address, bob.address, "10")
+	at(owner, 500, "transfer", initTokenName, owner.address, bob.address, "20")
+
+	expected = []struct {
+		address string
+		seconds int64
+		votes   string
+	}{
+		{owner.address, 499, strconv.Itoa(initAmount - 450)},
+		{owner.address, 500, strconv.Itoa(initAmount - 470)},
+		{owner.address, 999, strconv.Itoa(initAmount - 470)},
+		{bob.address, 500, "370"},
+	}
+	for _, checkpoint := range expected {
+		if votes := votesAt(checkpoint.address, checkpoint.seconds); votes != checkpoint.votes {
+			t.Fatal("unexpected past votes after future dated transfer", checkpoint.address, checkpoint.seconds, votes)
+		}
+	}
+
+	// a transaction backdated before too many checkpoints is rejected
+	for seconds := int64(1001); seconds <= 1010; seconds++ {
+		at(owner, seconds, "transfer", initTokenName, owner.address, bob.address, "1")
+	}
+
+	res = at(owner, 600, "transfer", initTokenName, owner.address, bob.address, "1")
+	if res.Status == shim.OK {
+		t.Fatal("transfer backdated before too many checkpoints must be rejected")
+	}
+
+	res = invokeAs(stub, owner, "txVotes", "getVotes", initTokenName, owner.address)
+	if string(res.Payload) != strconv.Itoa(initAmount-490) {
+		t.Fatal("unexpected votes", string(res.Payload))
+	}
+
+	res = invokeAs(stub, owner, "txVotes", "getVotes", initTokenName, bob.address)
+	if string(res.Payload) != "390" {
+		t.Fatal("unexpected votes", string(res.Payload))
+	}
+
+	res = invokeAs(stub, owner, "txDelegates", "delegates", initTokenName, bob.address)
+	if string(res.Payload) != bob.address {
+		t.Fatal("unexpected delegatee", string(res.Payload))
+	}
+}
+
+func TestFees(t *testing.T) {
+	stub, owner := configuration(t)
+	bob := newIdentity(t, "Org2MSP", "bob")
+	treasury := newIdentity(t, "Org2MSP", "treasury")
+
+	res := invokeAs(stub, bob, "txFee", "setFeeConfig", initTokenName, "100", "2", "50", treasury.address)
+	if res.Status != 403 {
+		t.Fatal("setFeeConfig without ADMIN role must be forbidden", res.Status)
+	}
+
+	// 1% fee, at least 2 and at most 50
+	res = invokeAs(stub, owner, "txFee", "setFeeConfig", initTokenName, "100", "2", "50", treasury.address)
+	if res.Status != shim.OK {
+		t.Fatal("setFeeConfig failed", res.Message)
+	}
+
+	quotes := map[string]string{"1000": "10", "100": "2", "10000": "50", "1": "1"}
+	for amount, fee := range quotes {
+		res = invokeAs(stub, owner, "txQuote", "quoteFee", initTokenName, amount, owner.address, bob.address)
+		quote := model.FeeQuote{}
+		json.Unmarshal(res.Payload, &quote)
+		if quote.Fee != fee {
+			t.Fatal("unexpected fee", amount, string(res.Payload))
+		}
+	}
+
+	for len(stub.ChaincodeEventsChannel) > 0 {
+		<-stub.ChaincodeEventsChannel
+	}
+
+	res = invokeAs(stub, owner, "txTransfer", "transfer", initTokenName, owner.address, bob.address, "1000")
+	if res.Status != shim.OK {
+		t.Fatal("transfer failed", res.Message)
+	}
+
+	events := nextEvents(t, stub)
+	eventBytes, _ := json.Marshal(model.NewTransferFeeEvent(initTokenName, owner.address, bob.address, big.NewInt(1000), big.NewInt(10), treasury.address))
+	if string(events[0].Payload) != string(eventBytes) {
+		t.Fatal("unexpected transfer event", string(events[0].Payload))
+	}
+
+	// allowance is spent by the amount including fee
+	invokeAs(stub, owner, "txApprove", "approve", initTokenName, owner.address, bob.address, "500")
+	res = invokeAs(stub, bob, "txTransferFrom", "transferFrom", initTokenName, owner.address, bob.address, bob.address, "500")
+	if res.Status != shim.OK {
+		t.Fatal("transferFrom failed", res.Message)
+	}
+
+	res = invokeAs(stub, owner, "txExempt", "setFeeExempt", "unknownToken", bob.address, "true")
+	if res.Status == shim.OK {
+		t.Fatal("fee exempt of unknown token must be rejected")
+	}
+
+	// exempt address pays no fee
+	invokeAs(stub, owner, "txExempt", "setFeeExempt", initTokenName, bob.address, "true")
+	invokeAs(stub, bob, "txTransfer", "transfer", initTokenName, bob.address, owner.address, "100")
+
+	ownerBalance, _ := repository.GetBalance(stub, initTokenName, owner.address, true)
+	bobBalance, _ := repository.GetBalance(stub, initTokenName, bob.address, true)
+	treasuryBalance, _ := repository.GetBalance(stub, initTokenName, treasury.address, true)
+	if ownerBalance.Int64() != initAmount-1400 || bobBalance.Int64() != 990+495-100 || treasuryBalance.Int64() != 15 {
+		t.Fatal("unexpected balances", ownerBalance, bobBalance, treasuryBalance)
+	}
+
+	// frozen treasury does not receive fees
+	carol := newIdentity(t, "Org2MSP", "carol")
+	invokeAs(stub, owner, "txFreeze", "freezeAccount", treasury.address)
+	res = invokeAs(stub, owner, "txTransfer", "transfer", initTokenName, owner.address, carol.address, "1000")
+	if res.Status == shim.OK {
+		t.Fatal("transfer paying fee to frozen treasury must be rejected")
+	}
+
+	res = invokeAs(stub, owner, "txBatch", "batchTransfer", initTokenName, owner.address, carol.address, "1000", bob.address, "100")
+	if res.Status == shim.OK {
+		t.Fatal("batch paying fee to frozen treasury must be rejected")
+	}
+
+	invokeAs(stub, owner, "txUnfreeze", "unfreezeAccount", treasury.address)
+	res = invokeAs(stub, owner, "txTransfer", "transfer", initTokenName, owner.address, carol.address, "1000")
+	if res.Status != shim.OK {
+		t.Fatal("transfer failed", res.Message)
+	}
+}
+
+func TestBatchTransfer(t *testing.T) {
+	stub, owner := configuration(t)
+	bob := newIdentity(t, "Org2MSP", "bob")
+	carol := newIdentity(t, "Org2MSP", "carol")
+
+	invokeAs(stub, owner, "txTransfer", "transfer", initTokenName, owner.address, bob.address, "1000")
+
+	// the whole batch fails when the total exceeds the balance
+	res := invokeAs(stub, bob, "txBatch", "batchTransfer", initTokenName, bob.address, carol.address, "600", owner.address, "500")
+	if res.Status == shim.OK {
+		t.Fatal("batch over balance must be rejected")
+	}
+
+	for len(stub.ChaincodeEventsChannel) > 0 {
+		<-stub.ChaincodeEventsChannel
+	}
+
+	// legs to the same recipient are summed
+	res = invokeAs(stub, bob, "txBatch", "batchTransfer", initTokenName, bob.address, carol.address, "300", owner.address, "200", carol.address, "100")
+	if res.Status != shim.OK {
+		t.Fatal("batchTransfer failed", res.Message)
+	}
+
+	events := nextEvents(t, stub)
+	event, err := decode.BatchTransfer(events[0])
+	if err != nil || event.Total != "600" || len(event.Legs) != 3 {
+		t.Fatal("unexpected batch event", string(events[0].Payload))
+	}
+
+	bobBalance, _ := repository.GetBalance(stub, initTokenName, bob.address, true)
+	carolBalance, _ := repository.GetBalance(stub, initTokenName, carol.address, true)
+	if bobBalance.Int64() != 400 || carolBalance.Int64() != 400 {
+		t.Fatal("unexpected balances", bobBalance, carolBalance)
+	}
+
+	recipients := `[{"recipient":"` + bob.address + `","amount":"10"},{"recipient":"` + carol.address + `","amount":"20"}]`
+	res = invokeAs(stub, bob, "txAirdrop", "airdrop", initTokenName, recipients)
+	if res.Status != 403 {
+		t.Fatal("airdrop without ADMIN role must be forbidden", res.Status)
+	}
+
+	res = invokeAs(stub, owner, "txAirdrop", "airdrop", initTokenName, recipients)
+	if res.Status != shim.OK {
+		t.Fatal("airdrop failed", res.Message)
+	}
+
+	ownerBalance, _ := repository.GetBalance(stub, initTokenName, owner.address, true)
+	carolBalance, _ = repository.GetBalance(stub, initTokenName, carol.address, true)
+	if ownerBalance.Int64() != initAmount-1000+200-30 || carolBalance.Int64() != 420 {
+		t.Fatal("unexpected balances after airdrop", ownerBalance, carolBalance)
+	}
+}
+
+func TestDeltaMode(t *testing.T) {
+	stub, owner := configuration(t)
+	merchant := newIdentity(t, "Org2MSP", "merchant")
+	bob := newIdentity(t, "Org2MSP", "bob")
+
+	deltaCount := func() int {
+		iterator, _ := stub.GetStateByPartialCompositeKey(repository.BalanceDeltaPrefix, []string{initTokenName, merchant.address})
+		defer iterator.Close()
+		count := 0
+		for ; iterator.HasNext(); count++ {
+			iterator.Next()
+		}
+		return count
+	}
+	baseBalance := func() string {
+		balanceKey, _ := repository.CreateBalanceKey(stub, initTokenName, merchant.address)
+		value, _ := stub.GetState(balanceKey)
+		return string(value)
+	}
+
+	res := invokeAs(stub, merchant, "txDelta", "setDeltaMode", initTokenName, merchant.address, "true")
+	if res.Status != 403 {
+		t.Fatal("setDeltaMode without ADMIN role must be forbidden", res.Status)
+	}
+
+	res = invokeAs(stub, owner, "txDelta", "setDeltaMode", initTokenName, merchant.address, "true")
+	if res.Status != shim.OK {
+		t.Fatal("setDeltaMode failed", res.Message)
+	}
+
+	// credits are saved as deltas, the base value is untouched
+	invokeAs(stub, owner, "txPay1", "transfer", initTokenName, owner.address, merchant.address, "100")
+	invokeAs(stub, owner, "txSnapshot", "snapshot", initTokenName)
+	invokeAs(stub, owner, "txPay2", "transfer", initTokenName, owner.address, merchant.address, "50")
+	if deltaCount() != 2 || baseBalance() != "0" {
+		t.Fatal("credits must be deltas", deltaCount(), baseBalance())
+	}
+
+	res = invokeAs(stub, owner, "txBalance", "balanceOf", initTokenName, merchant.address)
+	if string(res.Payload) != "150" {
+		t.Fatal("balance must include deltas", string(res.Payload))
+	}
+
+	res = invokeAs(stub, owner, "txBalanceAt", "balanceOfAt", initTokenName, merchant.address, "1")
+	if string(res.Payload) != "100" {
+		t.Fatal("snapshot must include deltas", string(res.Payload))
+	}
+
+	// debit folds the deltas into the base value
+	res = invokeAs(stub, merchant, "txSpend", "transfer", initTokenName, merchant.address, bob.address, "30")
+	if res.Status != shim.OK {
+		t.Fatal("transfer from delta mode address failed", res.Message)
+	}
+	if deltaCount() != 0 || baseBalance() != "120" {
+		t.Fatal("debit must fold deltas", deltaCount(), baseBalance())
+	}
+
+	invokeAs(stub, owner, "txPay3", "transfer", initTokenName, owner.address, merchant.address, "5")
+	res = invokeAs(stub, bob, "txCompact", "compactBalance", initTokenName, merchant.address)
+	if res.Status != shim.OK || deltaCount() != 0 || baseBalance() != "125" {
+		t.Fatal("compactBalance must fold deltas", res.Message, deltaCount(), baseBalance())
+	}
+
+	// disabling delta mode folds the deltas left, so later writes of the base value find none
+	invokeAs(stub, owner, "txPay4", "transfer", initTokenName, owner.address, merchant.address, "5")
+	res = invokeAs(stub, owner, "txDeltaOff", "setDeltaMode", initTokenName, merchant.address, "false")
+	if res.Status != shim.OK || deltaCount() != 0 || baseBalance() != "130" {
+		t.Fatal("disabling delta mode must fold deltas", res.Message, deltaCount(), baseBalance())
+	}
+
+	invokeAs(stub, owner, "txPay5", "transfer", initTokenName, owner.address, merchant.address, "5")
+	if deltaCount() != 0 || baseBalance() != "135" {
+		t.Fatal("credits must update the base value", deltaCount(), baseBalance())
+	}
+
+	// a credit in delta mode still reads and writes the fee treasury balance and the votes of the delegatee
+	treasury := newIdentity(t, "Org2MSP", "treasury")
+	invokeAs(stub, owner, "txDelta2", "setDeltaMode", initTokenName, merchant.address, "true")
+	invokeAs(stub, merchant, "txDelegate", "delegate", initTokenName, merchant.address, merchant.address)
+	invokeAs(stub, owner, "txFee", "setFeeConfig", initTokenName, "100", "2", "50", treasury.address)
+
+	res = invokeAs(stub, owner, "txPay6", "transfer", initTokenName, owner.address, merchant.address, "100")
+	if res.Status != shim.OK || deltaCount() != 1 || baseBalance() != "135" {
+		t.Fatal("credit must be a delta", res.Message, deltaCount(), baseBalance())
+	}
+
+	treasuryKey, _ := repository.CreateBalanceKey(stub, initTokenName, treasury.address)
+	treasuryBalance, _ := stub.GetState(treasuryKey)
+	if string(treasuryBalance) != "2" {
+		t.Fatal("fee must be written to the treasury base value", string(treasuryBalance))
+	}
+
+	res = invokeAs(stub, owner, "txVotes", "getVotes", initTokenName, merchant.address)
+	if string(res.Payload) != "233" {
+		t.Fatal("credit must write the votes of the delegatee", string(res.Payload))
+	}
+}
+
+func TestEventEnvelope(t *testing.T) {
+	stub, owner := configuration(t)
+	bob := newIdentity(t, "Org2MSP", "bob")
+
+	for len(stub.ChaincodeEventsChannel) > 0 {
+		<-stub.ChaincodeEventsChannel
+	}
+
+	invokeAs(stub, owner, "txApprove", "approve", initTokenName, owner.address, bob.address, "500")
+	nextEvents(t, stub)
+
+	// transferFrom keeps both the transfer and the approval event
+	res := invokeAs(stub, bob, "txTransferFrom", "transferFrom", initTokenName, owner.address, bob.address, bob.address, "200")
+	if res.Status != shim.OK {
+		t.Fatal("transferFrom failed", res.Message)
+	}
+
+	events := nextEvents(t, stub)
+	if len(events) != 2 {
+		t.Fatal("unexpected events", events)
+	}
+
+	transferEvent, err := decode.Transfer(events[0])
+	if err != nil || transferEvent.Amount != "200" || transferEvent.Recipient != bob.address {
+		t.Fatal("unexpected transfer event", string(events[0].Payload))
+	}
+
+	approval, err := decode.Approval(events[1])
+	if err != nil || approval.Allowance != "300" {
+		t.Fatal("unexpected approval event", string(events[1].Payload))
+	}
+
+	if _, err = decode.Approval(events[0]); err == nil {
+		t.Fatal("transfer event must not decode as approval")
+	}
+
+	// failed transaction emits nothing
+	res = invokeAs(stub, bob, "txTransfer", "transfer", initTokenName, bob.address, owner.address, "1000")
+	if res.Status == shim.OK {
+		t.Fatal("transfer over balance must be rejected")
+	}
+	if len(stub.ChaincodeEventsChannel) != 0 {
+		t.Fatal("failed transaction must not emit events")
+	}
+
+	// events emitted before the envelope decode as a single record
+	legacy, _ := json.Marshal(model.NewTransferEvent(initTokenName, owner.address, bob.address, big.NewInt(1)))
+	events, err = decode.Events(repository.TransferEventKey, legacy)
+	if err != nil || len(events) != 1 || events[0].Name != repository.TransferEventKey {
+		t.Fatal("unexpected legacy events", events)
+	}
+
+	if _, err = decode.Envelope([]byte(`{"version":2,"events":[]}`)); err == nil {
+		t.Fatal("unsupported version must be rejected")
+	}
+}
+
+func TestAuditSupply(t *testing.T) {
+	stub, owner := configuration(t)
+	bob := newIdentity(t, "Org2MSP", "bob")
+	carol := newIdentity(t, "Org2MSP", "carol")
+
+	invokeAs(stub, owner, "txDelta", "setDeltaMode", initTokenName, carol.address, "true")
+	invokeAs(stub, owner, "txTransfer1", "transfer", initTokenName, owner.address, bob.address, "300")
+	invokeAs(stub, owner, "txTransfer2", "transfer", initTokenName, owner.address, carol.address, "200")
+
+	res := invokeAs(stub, bob, "txAudit", "auditSupply", initTokenName, "10")
+	if res.Status != 403 {
+		t.Fatal("auditSupply without ADMIN role must be forbidden", res.Status)
+	}
+
+	res = invokeAs(stub, owner, "txAudit", "auditSupply", initTokenName, "2")
+	if res.Status != shim.OK {
+		t.Fatal("auditSupply failed", res.Message)
+	}
+
+	audit := model.SupplyAudit{}
+	json.Unmarshal(res.Payload, &audit)
+	if !audit.Consistent || audit.BalanceSum != strconv.Itoa(initAmount) || audit.HolderCount != 3 || len(audit.Discrepancies) != 0 {
+		t.Fatal("unexpected audit", string(res.Payload))
+	}
+	if len(audit.LargestHolders) != 2 || audit.LargestHolders[0].Address != owner.address || audit.LargestHolders[1].Address != bob.address {
+		t.Fatal("unexpected largest holders", audit.LargestHolders)
+	}
+
+	// tamper a balance and add a value which is not an integer
+	stub.MockTransactionStart("txTamper")
+	bobKey, _ := repository.CreateBalanceKey(stub, initTokenName, bob.address)
+	stub.PutState(bobKey, []byte("310"))
+	carolKey, _ := repository.CreateBalanceKey(stub, initTokenName, carol.address)
+	stub.PutState(carolKey, []byte("1e3"))
+	stub.MockTransactionEnd("txTamper")
+
+	res = invokeAs(stub, owner, "txAudit", "auditSupply", initTokenName, "10")
+	audit = model.SupplyAudit{}
+	json.Unmarshal(res.Payload, &audit)
+	if audit.Consistent || audit.Difference != "10" || len(audit.Unparseable) != 1 || audit.Unparseable[0].Value != "1e3" {
+		t.Fatal("unexpected audit", string(res.Payload))
+	}
+
+	// the delta of carol is still counted
+	if audit.HolderCount != 3 || len(audit.Discrepancies) != 2 {
+		t.Fatal("unexpected audit", string(res.Payload))
+	}
+}
+
+func TestRouter(t *testing.T) {
+	cc := NewChaincode()
+	stub := shimtest.NewMockStub("erc20", cc)
+	owner := newIdentity(t, "Org1MSP", "owner")
+	bob := newIdentity(t, "Org2MSP", "bob")
+	stub.MockInit("1", [][]byte{[]byte("Init"), []byte(initTokenName), []byte(initSymbol), []byte(owner.address), []byte(strconv.Itoa(initAmount)), []byte(initDecimals)})
+
+	res := invokeAs(stub, bob, "txList", "listFunctions")
+	if res.Status != shim.OK {
+		t.Fatal("listFunctions failed", res.Message)
+	}
+
+	functions := []controller.Function{}
+	json.Unmarshal(res.Payload, &functions)
+	described := map[string]controller.Function{}
+	for _, function := range functions {
+		described[function.Name] = function
+	}
+	if mint := described["mint"]; len(mint.Params) != 3 || mint.ReadOnly || !mint.Pausable || mint.Roles[0] != model.MinterRole {
+		t.Fatal("unexpected mint description", mint)
+	}
+	if !described["balanceOf"].ReadOnly || !described["listFunctions"].ReadOnly {
+		t.Fatal("queries must be read only")
+	}
+
+	// every routed fnc is logged with the status of its response
+	var logs bytes.Buffer
+	controller.Logger.SetOutput(&logs)
+	defer controller.Logger.SetOutput(os.Stderr)
+
+	invokeAs(stub, owner, "txLogOk", "balanceOf", initTokenName, owner.address)
+	invokeAs(stub, bob, "txLogFail", "mint", initTokenName, bob.address, "10")
+	if !strings.Contains(logs.String(), "balanceOf in tx txLogOk succeeded") || !strings.Contains(logs.String(), "mint in tx txLogFail failed with 403") {
+		t.Fatal("unexpected logs", logs.String())
+	}
+
+	res = invokeAs(stub, bob, "txUnknown", "unknown")
+	if res.Status != 404 {
+		t.Fatal("unknown function must be not found", res.Status)
+	}
+
+	// params are checked before the handler
+	res = invokeAs(stub, owner, "txTransfer", "transfer", initTokenName, owner.address, bob.address)
+	if res.Status == shim.OK || res.Message != "transfer takes params tokenName, caller, recipient, amount" {
+		t.Fatal("unexpected response", res.Message)
+	}
+
+	res = invokeAs(stub, owner, "txBatch", "batchTransfer", initTokenName, owner.address, bob.address, "1", bob.address)
+	if res.Status == shim.OK {
+		t.Fatal("incomplete batch leg must be rejected")
+	}
+
+	res = invokeAs(stub, owner, "txCreate", "createToken", "otherToken", "ot", owner.address, "100", "2", "1000", "extra")
+	if res.Status == shim.OK {
+		t.Fatal("too many params must be rejected")
+	}
+
+	// role is checked before the handler
+	res = invokeAs(stub, bob, "txMint", "mint", initTokenName, bob.address, "10")
+	if res.Status != 403 {
+		t.Fatal("mint without MINTER role must be forbidden", res.Status)
+	}
+
+	// read only function can not write
+	cc.Router.Register(controller.Function{Name: "writeInQuery", ReadOnly: true, Handler: func(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+		err := stub.PutState("key", []byte("value"))
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+		return shim.Success(nil)
+	}})
+
+	res = invokeAs(stub, bob, "txWrite", "writeInQuery")
+	if res.Status == shim.OK {
+		t.Fatal("read only function must not write")
+	}
+
+	// panic is an error response
+	cc.Router.Register(controller.Function{Name: "panicking", Handler: func(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+		var metadata *model.ERC20Metadata
+		return shim.Success([]byte(metadata.Name))
+	}})
+
+	res = invokeAs(stub, bob, "txPanic", "panicking")
+	if res.Status != shim.ERROR {
+		t.Fatal("panic must be recovered", res.Status)
+	}
 }
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+// NewHandler returns the http query api of the indexer
+// GET /status, /balance, /holders, /allowance, /allowances, /transfers
+func NewHandler(indexer *Indexer) http.Handler {
+
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"startBlock": indexer.store.StartBlock(),
+			"nextBlock":  indexer.store.NextBlock(),
+			"gap":        indexer.Gap(),
+		})
+	})
+
+	mux.HandleFunc("/balance", func(w http.ResponseWriter, r *http.Request) {
+		params, ok := requireQuery(w, r, "token", "address")
+		if !ok {
+			return
+		}
+		writeJSON(w, http.StatusOK, Holder{Address: params[1], Balance: indexer.store.Balance(params[0], params[1])})
+	})
+
+	mux.HandleFunc("/holders", func(w http.ResponseWriter, r *http.Request) {
+		params, ok := requireQuery(w, r, "token")
+		if !ok {
+			return
+		}
+		limit, ok := queryLimit(w, r)
+		if !ok {
+			return
+		}
+		writeJSON(w, http.StatusOK, indexer.store.Holders(params[0], limit))
+	})
+
+	mux.HandleFunc("/allowance", func(w http.ResponseWriter, r *http.Request) {
+		params, ok := requireQuery(w, r, "token", "owner", "spender")
+		if !ok {
+			return
+		}
+		writeJSON(w, http.StatusOK, indexer.store.Allowance(params[0], params[1], params[2]))
+	})
+
+	mux.HandleFunc("/allowances", func(w http.ResponseWriter, r *http.Request) {
+		params, ok := requireQuery(w, r, "token", "owner")
+		if !ok {
+			return
+		}
+		writeJSON(w, http.StatusOK, indexer.store.Allowances(params[0], params[1]))
+	})
+
+	mux.HandleFunc("/transfers", func(w http.ResponseWriter, r *http.Request) {
+		params, ok := requireQuery(w, r, "token")
+		if !ok {
+			return
+		}
+		limit, ok := queryLimit(w, r)
+		if !ok {
+			return
+		}
+		writeJSON(w, http.StatusOK, indexer.store.Transfers(params[0], r.URL.Query().Get("address"), limit))
+	})
+
+	return mux
+}
+
+// requireQuery returns the values of names, every name must be set
+func requireQuery(w http.ResponseWriter, r *http.Request, names ...string) ([]string, bool) {
+
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "only GET is allowed"})
+		return nil, false
+	}
+
+	values := []string{}
+	for _, name := range names {
+		value := r.URL.Query().Get(name)
+		if value == "" {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": name + " is required"})
+			return nil, false
+		}
+		values = append(values, value)
+	}
+
+	return values, true
+}
+
+// queryLimit returns the limit query, 0 means no limit
+func queryLimit(w http.ResponseWriter, r *http.Request) (int, bool) {
+
+	limitString := r.URL.Query().Get("limit")
+	if limitString == "" {
+		return 0, true
+	}
+
+	limit, err := strconv.Atoi(limitString)
+	if err != nil || limit < 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "limit must be a non negative integer"})
+		return 0, false
+	}
+
+	return limit, true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"fmt"
+	"hyperledger_dapp/decode"
+	"hyperledger_dapp/identity"
+	"hyperledger_dapp/model"
+	"hyperledger_dapp/repository"
+	"math/big"
+	"sync"
+)
+
+// Gap is a block missing from the source
+// indexing stops at the gap until the source delivers the expected block
+type Gap struct {
+	Expected uint64 `json:"expected"`
+	Found    uint64 `json:"found"`
+}
+
+// Indexer reads blocks from source and applies their events to store
+type Indexer struct {
+	source Source
+	store  *Store
+	mutex  sync.RWMutex
+	gap    *Gap
+}
+
+func NewIndexer(source Source, store *Store) *Indexer {
+	return &Indexer{source: source, store: store}
+}
+
+// Gap returns the gap found by the last Sync, nil if there was none
+func (indexer *Indexer) Gap() *Gap {
+	indexer.mutex.RLock()
+	defer indexer.mutex.RUnlock()
+	return indexer.gap
+}
+
+// Sync applies the blocks from the next block of store, each block is saved as it is applied
+// blocks already applied are skipped, so a source can replay from any block
+// return - number of applied blocks
+func (indexer *Indexer) Sync() (int, error) {
+
+	blocks, err := indexer.source.Blocks(indexer.store.NextBlock())
+	if err != nil {
+		return 0, err
+	}
+
+	var gap *Gap
+	applied := 0
+	for _, block := range blocks {
+		next := indexer.store.NextBlock()
+		if block.Number < next {
+			continue
+		}
+
+		if block.Number > next {
+			gap = &Gap{Expected: next, Found: block.Number}
+			break
+		}
+
+		update, err := newBlockUpdate(block)
+		if err != nil {
+			return applied, err
+		}
+
+		err = indexer.store.apply(update)
+		if err != nil {
+			return applied, err
+		}
+		applied++
+	}
+
+	indexer.mutex.Lock()
+	indexer.gap = gap
+	indexer.mutex.Unlock()
+
+	return applied, nil
+}
+
+type balanceDelta struct {
+	token   string
+	address string
+	amount  *big.Int
+}
+
+type allowanceUpdate struct {
+	token   string
+	owner   string
+	spender string
+	amount  string
+}
+
+// blockUpdate is every change of a block, decoded before the store is touched
+type blockUpdate struct {
+	number     uint64
+	balances   []balanceDelta
+	allowances []allowanceUpdate
+	transfers  []Transfer
+}
+
+func newBlockUpdate(block Block) (*blockUpdate, error) {
+
+	update := &blockUpdate{number: block.Number}
+	for _, event := range block.Events {
+		records, err := decode.Events(event.Name, event.Payload)
+		if err != nil {
+			return nil, fmt.Errorf("failed to index block %d, error : %s", block.Number, err.Error())
+		}
+
+		for _, record := range records {
+			err = update.add(event.TxID, record)
+			if err != nil {
+				return nil, fmt.Errorf("failed to index block %d, error : %s", block.Number, err.Error())
+			}
+		}
+	}
+
+	return update, nil
+}
+
+// add decodes record into the update, events which don't move balances or allowances are ignored
+func (update *blockUpdate) add(txID string, record model.EventRecord) error {
+
+	switch record.Name {
+	case repository.TransferEventKey:
+		transferEvent, err := decode.Transfer(record)
+		if err != nil {
+			return err
+		}
+
+		return update.addTransfer(Transfer{
+			Block:     update.number,
+			TxID:      txID,
+			Event:     record.Name,
+			Token:     transferEvent.Token,
+			Sender:    transferEvent.Sender,
+			Recipient: transferEvent.Recipient,
+			Amount:    transferEvent.Amount,
+			Fee:       transferEvent.Fee,
+			Treasury:  transferEvent.Treasury,
+		})
+
+	case repository.BatchTransferEventKey, repository.AirdropEventKey:
+		batchTransferEvent, err := decode.BatchTransfer(record)
+		if err != nil {
+			return err
+		}
+
+		for _, leg := range batchTransferEvent.Legs {
+			transfer := Transfer{
+				Block:     update.number,
+				TxID:      txID,
+				Event:     record.Name,
+				Token:     batchTransferEvent.Token,
+				Sender:    batchTransferEvent.Sender,
+				Recipient: leg.Recipient,
+				Amount:    leg.Amount,
+				Fee:       leg.Fee,
+			}
+			if leg.Fee != "" {
+				transfer.Treasury = batchTransferEvent.Treasury
+			}
+
+			err = update.addTransfer(transfer)
+			if err != nil {
+				return err
+			}
+		}
+
+	case repository.ApprovalEventKey:
+		approval, err := decode.Approval(record)
+		if err != nil {
+			return err
+		}
+
+		update.allowances = append(update.allowances, allowanceUpdate{
+			token:   approval.Token,
+			owner:   approval.Owner,
+			spender: approval.Spender,
+			amount:  approval.Allowance,
+		})
+	}
+
+	return nil
+}
+
+// addTransfer moves amount from sender, amount - fee to recipient and fee to treasury
+// zero address is the other side of mint and burn, so it has no balance
+func (update *blockUpdate) addTransfer(transfer Transfer) error {
+
+	amount, ok := new(big.Int).SetString(transfer.Amount, 10)
+	if !ok {
+		return fmt.Errorf("invalid amount %s of %s", transfer.Amount, transfer.Event)
+	}
+
+	fee := new(big.Int)
+	if transfer.Fee != "" {
+		fee, ok = fee.SetString(transfer.Fee, 10)
+		if !ok {
+			return fmt.Errorf("invalid fee %s of %s", transfer.Fee, transfer.Event)
+		}
+	}
+
+	update.addBalance(transfer.Token, transfer.Sender, new(big.Int).Neg(amount))
+	update.addBalance(transfer.Token, transfer.Recipient, new(big.Int).Sub(amount, fee))
+	if fee.Sign() > 0 {
+		update.addBalance(transfer.Token, transfer.Treasury, fee)
+	}
+
+	update.transfers = append(update.transfers, transfer)
+	return nil
+}
+
+func (update *blockUpdate) addBalance(token, address string, amount *big.Int) {
+	if address == identity.ZeroAddress {
+		return
+	}
+	update.balances = append(update.balances, balanceDelta{token: token, address: address, amount: amount})
+}
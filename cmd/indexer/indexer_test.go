@@ -0,0 +1,258 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"hyperledger_dapp/controller"
+	"hyperledger_dapp/identity"
+	"hyperledger_dapp/model"
+	"hyperledger_dapp/repository"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/hyperledger/fabric-protos-go/msp"
+	sc "github.com/hyperledger/fabric-protos-go/peer"
+)
+
+const token = "dappToken"
+
+// chaincode is wired like the chaincode package main, which cannot be imported
+type chaincode struct {
+	controller *controller.Controller
+	router     *controller.Router
+}
+
+func (cc *chaincode) Init(stub shim.ChaincodeStubInterface) sc.Response {
+	_, params := stub.GetFunctionAndParameters()
+	return controller.EmitEvents(stub, func(stub shim.ChaincodeStubInterface) sc.Response {
+		return cc.controller.Init(stub, params)
+	})
+}
+
+func (cc *chaincode) Invoke(stub shim.ChaincodeStubInterface) sc.Response {
+	fnc, params := stub.GetFunctionAndParameters()
+	return controller.EmitEvents(stub, func(stub shim.ChaincodeStubInterface) sc.Response {
+		return cc.router.Handle(stub, fnc, params)
+	})
+}
+
+// ledger runs the chaincode and keeps one block per transaction with the events it emitted
+type ledger struct {
+	t      *testing.T
+	stub   *shimtest.MockStub
+	blocks []Block
+}
+
+func newLedger(t *testing.T) *ledger {
+	cc := controller.NewContoller()
+	return &ledger{t: t, stub: shimtest.NewMockStub("erc20", &chaincode{controller: cc, router: cc.Routes()})}
+}
+
+func (ledger *ledger) init(creator []byte, args ...string) {
+	ledger.stub.Creator = creator
+	ledger.record(ledger.stub.MockInit(ledger.txID(), arguments(append([]string{"Init"}, args...))))
+}
+
+func (ledger *ledger) invoke(creator []byte, args ...string) {
+	ledger.stub.Creator = creator
+	ledger.record(ledger.stub.MockInvoke(ledger.txID(), arguments(args)))
+}
+
+func (ledger *ledger) txID() string {
+	return "tx" + strconv.Itoa(len(ledger.blocks))
+}
+
+func (ledger *ledger) record(res sc.Response) {
+	if res.Status != shim.OK {
+		ledger.t.Fatal("transaction failed", res.Message)
+	}
+
+	block := Block{Number: uint64(len(ledger.blocks))}
+	for len(ledger.stub.ChaincodeEventsChannel) > 0 {
+		event := <-ledger.stub.ChaincodeEventsChannel
+		block.Events = append(block.Events, Event{TxID: ledger.txID(), Name: event.EventName, Payload: event.Payload})
+	}
+	ledger.blocks = append(ledger.blocks, block)
+}
+
+func arguments(args []string) [][]byte {
+	arguments := [][]byte{}
+	for _, arg := range args {
+		arguments = append(arguments, []byte(arg))
+	}
+	return arguments
+}
+
+// newCreator makes a self-signed X.509 identity and returns it with its address
+func newCreator(t *testing.T, commonName string) ([]byte, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER})
+	creator, err := proto.Marshal(&msp.SerializedIdentity{Mspid: "Org1MSP", IdBytes: certPEM})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stub := shimtest.NewMockStub("identity", nil)
+	stub.Creator = creator
+	address, err := identity.GetCallerAddress(stub)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return creator, address
+}
+
+func writeBlocks(t *testing.T, path string, blocks ...Block) {
+	lines := []byte{}
+	for _, block := range blocks {
+		blockBytes, err := json.Marshal(block)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines = append(append(lines, blockBytes...), '\n')
+	}
+	err := ioutil.WriteFile(path, lines, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestIndexer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "indexer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	sourcePath := filepath.Join(dir, "blocks.jsonl")
+	dbPath := filepath.Join(dir, "indexer.db")
+
+	aliceCreator, alice := newCreator(t, "alice")
+	bobCreator, bob := newCreator(t, "bob")
+	_, carol := newCreator(t, "carol")
+	_, fund := newCreator(t, "fund")
+
+	// every block is a transaction of the chaincode, starting from the initial supply
+	ledger := newLedger(t)
+	ledger.init(aliceCreator, token, "dt", alice, "1000", "0")
+	ledger.invoke(aliceCreator, "setFeeConfig", token, "100", "0", "0", fund)
+	ledger.invoke(aliceCreator, "approve", token, alice, bob, "500")
+	ledger.invoke(bobCreator, "transferFrom", token, alice, bob, bob, "100")
+	ledger.invoke(aliceCreator, "grantRole", model.BurnerRole, bob, token)
+	ledger.invoke(bobCreator, "burn", token, bob, "9")
+	ledger.invoke(aliceCreator, "airdrop", token, `[{"recipient":"`+bob+`","amount":"10"},{"recipient":"`+carol+`","amount":"20"}]`)
+	blocks := ledger.blocks
+
+	// block 5 is missing
+	writeBlocks(t, sourcePath, append(append([]Block{}, blocks[:5]...), blocks[6])...)
+
+	store, err := OpenStore(dbPath, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	indexer := NewIndexer(NewFileSource(sourcePath), store)
+
+	applied, err := indexer.Sync()
+	if err != nil || applied != 5 {
+		t.Fatal("unexpected sync", applied, err)
+	}
+
+	gap := indexer.Gap()
+	if gap == nil || gap.Expected != 5 || gap.Found != 6 {
+		t.Fatal("gap must be found", gap)
+	}
+
+	if store.Balance(token, alice) != "900" || store.Balance(token, bob) != "99" || store.Balance(token, fund) != "1" {
+		t.Fatal("unexpected balances", store.Holders(token, 0))
+	}
+	if store.Allowance(token, alice, bob) != "400" {
+		t.Fatal("unexpected allowance", store.Allowance(token, alice, bob))
+	}
+
+	// the missing block arrives and the source replays from the beginning
+	writeBlocks(t, sourcePath, blocks...)
+
+	// a reopened store continues from the saved block and keeps the latest 3 transfers
+	store.Close()
+	store, err = OpenStore(dbPath, 0, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	indexer = NewIndexer(NewFileSource(sourcePath), store)
+
+	applied, err = indexer.Sync()
+	if err != nil || applied != 2 || indexer.Gap() != nil || store.NextBlock() != 7 {
+		t.Fatal("unexpected sync", applied, err, indexer.Gap())
+	}
+
+	holders := store.Holders(token, 0)
+	if len(holders) != 4 || holders[0].Address != alice || holders[0].Balance != "870" || store.Balance(token, bob) != "100" || store.Balance(token, carol) != "20" {
+		t.Fatal("unexpected holders", holders)
+	}
+
+	// burn and the airdrop leg of bob
+	transfers := store.Transfers(token, bob, 2)
+	if len(transfers) != 2 || transfers[0].Event != repository.AirdropEventKey || transfers[1].Recipient != identity.ZeroAddress {
+		t.Fatal("unexpected transfers", transfers)
+	}
+
+	// older transfers are dropped, the balances are kept
+	transfers = store.Transfers(token, "", 0)
+	if len(transfers) != 3 || transfers[2].Event != repository.TransferEventKey || transfers[2].Recipient != identity.ZeroAddress {
+		t.Fatal("unexpected kept transfers", transfers)
+	}
+
+	server := httptest.NewServer(NewHandler(indexer))
+	defer server.Close()
+
+	res, err := http.Get(server.URL + "/balance?token=" + token + "&address=" + carol)
+	if err != nil {
+		t.Fatal(err)
+	}
+	holder := Holder{}
+	json.NewDecoder(res.Body).Decode(&holder)
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK || holder.Balance != "20" {
+		t.Fatal("unexpected balance response", res.StatusCode, holder)
+	}
+
+	res, err = http.Get(server.URL + "/holders")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusBadRequest {
+		t.Fatal("token must be required", res.StatusCode)
+	}
+}
@@ -0,0 +1,107 @@
+// Command indexer builds a queryable database of token holders from the chaincode events
+//
+// blocks are replayed from a file with one json block per line:
+//
+//	{"number":12,"events":[{"txId":"...","name":"eventEnvelope","payload":{"version":1,"events":[...]}}]}
+//
+// usage: indexer -source blocks.jsonl -db indexer.db -addr :8080
+//
+// balances are summed from the transfers, so an empty store must start at or before the block of the first event of the chaincode
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+func main() {
+
+	sourcePath := flag.String("source", "blocks.jsonl", "file with one json block per line")
+	dbPath := flag.String("db", "indexer.db", "file of the embedded store")
+	start := flag.Uint64("start", 0, "first block to index when the store is empty")
+	keepTransfers := flag.Uint64("keep-transfers", 100000, "number of the latest transfers kept per token, 0 keeps every transfer")
+	addr := flag.String("addr", ":8080", "address of the http query api")
+	interval := flag.Duration("interval", 5*time.Second, "interval to read new blocks from source")
+	flag.Parse()
+
+	store, err := OpenStore(*dbPath, *start, *keepTransfers)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// balances are summed from block start, the events before it are missing
+	if store.StartBlock() > 0 {
+		log.Printf("warning: store starts from block %d, balances are wrong if the chaincode emitted events before it", store.StartBlock())
+	}
+
+	indexer := NewIndexer(NewFileSource(*sourcePath), store)
+
+	// SIGINT and SIGTERM stop the sync loop and the query api, so the store is closed cleanly
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	done := make(chan struct{})
+
+	synced := make(chan struct{})
+	go func() {
+		defer close(synced)
+		for {
+			applied, err := indexer.Sync()
+			if err != nil {
+				log.Println(err)
+			}
+			if applied > 0 {
+				log.Printf("indexed %d blocks, next block is %d", applied, store.NextBlock())
+			}
+			if gap := indexer.Gap(); gap != nil {
+				log.Printf("gap in source, expected block %d but found %d", gap.Expected, gap.Found)
+			}
+
+			select {
+			case <-done:
+				return
+			case <-time.After(*interval):
+			}
+		}
+	}()
+
+	server := &http.Server{Addr: *addr, Handler: NewHandler(indexer)}
+
+	served := make(chan error, 1)
+	go func() {
+		log.Printf("serving query api on %s", *addr)
+		served <- server.ListenAndServe()
+	}()
+
+	// log.Fatal skips deferred calls, so the error is kept until the store is closed
+	var serveErr error
+	select {
+	case serveErr = <-served:
+	case sig := <-signals:
+		log.Printf("received %s, shutting down", sig)
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		serveErr = server.Shutdown(shutdownCtx)
+		cancel()
+		if err := <-served; !errors.Is(err, http.ErrServerClosed) {
+			serveErr = err
+		}
+	}
+
+	// the store is closed only after the last sync finished writing
+	close(done)
+	<-synced
+	if err := store.Close(); err != nil {
+		log.Println(err)
+	}
+
+	if serveErr != nil {
+		log.Println(serveErr)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// Event is a chaincode event of a block
+// payload is the envelope or, for blocks committed before the envelope, a single event
+type Event struct {
+	TxID    string          `json:"txId"`
+	Name    string          `json:"name"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+// Block is a committed block with the chaincode events of its valid transactions
+// blocks without chaincode events are delivered too, so the numbers have no gap
+type Block struct {
+	Number uint64  `json:"number"`
+	Events []Event `json:"events"`
+}
+
+// Source delivers committed blocks in block number order
+type Source interface {
+	// Blocks returns the blocks whose number is from or greater
+	Blocks(from uint64) ([]Block, error)
+}
+
+// FileSource replays blocks from a file with one json block per line
+// the file is read again on every call, so blocks appended to it are picked up
+type FileSource struct {
+	Path string
+}
+
+func NewFileSource(path string) *FileSource {
+	return &FileSource{Path: path}
+}
+
+func (source *FileSource) Blocks(from uint64) ([]Block, error) {
+
+	file, err := os.Open(source.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s, error : %s", source.Path, err.Error())
+	}
+	defer file.Close()
+
+	blocks := []Block{}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		block := Block{}
+		err = json.Unmarshal(scanner.Bytes(), &block)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read block at line %d, error : %s", line, err.Error())
+		}
+
+		if block.Number >= from {
+			blocks = append(blocks, block)
+		}
+	}
+
+	err = scanner.Err()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s, error : %s", source.Path, err.Error())
+	}
+
+	sort.SliceStable(blocks, func(i, j int) bool {
+		return blocks[i].Number < blocks[j].Number
+	})
+
+	return blocks, nil
+}
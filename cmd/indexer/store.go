@@ -0,0 +1,355 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Transfer is one balance move found in the events
+// batch transfers and airdrops are kept as one transfer per leg
+type Transfer struct {
+	Block     uint64 `json:"block"`
+	TxID      string `json:"txId"`
+	Event     string `json:"event"`
+	Token     string `json:"token"`
+	Sender    string `json:"sender"`
+	Recipient string `json:"recipient"`
+	Amount    string `json:"amount"`
+	Fee       string `json:"fee,omitempty"`
+	Treasury  string `json:"treasury,omitempty"`
+}
+
+// Holder is an address and its balance
+type Holder struct {
+	Address string `json:"address"`
+	Balance string `json:"balance"`
+}
+
+// buckets of the store, balances, allowances and transfers have a nested bucket per token
+// balances - address : amount, allowances - owner 0x00 spender : amount, transfers - sequence : json of Transfer
+var (
+	metaBucket       = []byte("meta")
+	balancesBucket   = []byte("balances")
+	allowancesBucket = []byte("allowances")
+	transfersBucket  = []byte("transfers")
+
+	nextBlockKey  = []byte("nextBlock")
+	startBlockKey = []byte("startBlock")
+)
+
+// Store is an embedded key-value store on bbolt
+// every block is written in one transaction, so a crash leaves the state of the last applied block
+type Store struct {
+	db            *bolt.DB
+	keepTransfers uint64
+}
+
+// OpenStore opens the store at path, an empty store starts from block start
+// only the latest keepTransfers transfers of a token are kept, 0 keeps every transfer
+func OpenStore(path string, start uint64, keepTransfers uint64) (*Store, error) {
+
+	db, err := bolt.Open(path, 0644, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s, error : %s", path, err.Error())
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{balancesBucket, allowancesBucket, transfersBucket} {
+			_, err := tx.CreateBucketIfNotExists(name)
+			if err != nil {
+				return err
+			}
+		}
+
+		meta, err := tx.CreateBucketIfNotExists(metaBucket)
+		if err != nil {
+			return err
+		}
+
+		if meta.Get(nextBlockKey) != nil {
+			return nil
+		}
+
+		err = meta.Put(startBlockKey, encodeUint64(start))
+		if err != nil {
+			return err
+		}
+		return meta.Put(nextBlockKey, encodeUint64(start))
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize %s, error : %s", path, err.Error())
+	}
+
+	return &Store{db: db, keepTransfers: keepTransfers}, nil
+}
+
+// Close releases the file of the store
+func (store *Store) Close() error {
+	return store.db.Close()
+}
+
+// StartBlock returns the number of the first block the store indexed
+// balances are complete only if no event of the chaincode comes before it
+func (store *Store) StartBlock() uint64 {
+	return store.metaValue(startBlockKey)
+}
+
+// NextBlock returns the number of the block to index next
+func (store *Store) NextBlock() uint64 {
+	return store.metaValue(nextBlockKey)
+}
+
+func (store *Store) metaValue(key []byte) uint64 {
+	var value uint64
+	store.db.View(func(tx *bolt.Tx) error {
+		value = decodeUint64(tx.Bucket(metaBucket).Get(key))
+		return nil
+	})
+	return value
+}
+
+// apply writes the update of block and moves to the next block in one transaction
+func (store *Store) apply(update *blockUpdate) error {
+
+	return store.db.Update(func(tx *bolt.Tx) error {
+
+		meta := tx.Bucket(metaBucket)
+		next := decodeUint64(meta.Get(nextBlockKey))
+		if update.number != next {
+			return fmt.Errorf("failed to apply block %d, error : next block is %d", update.number, next)
+		}
+
+		for _, delta := range update.balances {
+			balances, err := tx.Bucket(balancesBucket).CreateBucketIfNotExists([]byte(delta.token))
+			if err != nil {
+				return err
+			}
+
+			balance := parseAmount(string(balances.Get([]byte(delta.address))))
+			balance.Add(balance, delta.amount)
+			if balance.Sign() == 0 {
+				err = balances.Delete([]byte(delta.address))
+			} else {
+				err = balances.Put([]byte(delta.address), []byte(balance.String()))
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, allowance := range update.allowances {
+			allowances, err := tx.Bucket(allowancesBucket).CreateBucketIfNotExists([]byte(allowance.token))
+			if err != nil {
+				return err
+			}
+
+			key := allowanceKey(allowance.owner, allowance.spender)
+			if parseAmount(allowance.amount).Sign() == 0 {
+				err = allowances.Delete(key)
+			} else {
+				err = allowances.Put(key, []byte(allowance.amount))
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, transfer := range update.transfers {
+			err := store.putTransfer(tx, transfer)
+			if err != nil {
+				return err
+			}
+		}
+
+		return meta.Put(nextBlockKey, encodeUint64(next+1))
+	})
+}
+
+// putTransfer appends transfer and drops the oldest transfers of its token beyond keepTransfers
+func (store *Store) putTransfer(tx *bolt.Tx, transfer Transfer) error {
+
+	transfers, err := tx.Bucket(transfersBucket).CreateBucketIfNotExists([]byte(transfer.Token))
+	if err != nil {
+		return err
+	}
+
+	sequence, err := transfers.NextSequence()
+	if err != nil {
+		return err
+	}
+
+	transferBytes, err := json.Marshal(transfer)
+	if err != nil {
+		return err
+	}
+
+	err = transfers.Put(encodeUint64(sequence), transferBytes)
+	if err != nil {
+		return err
+	}
+
+	if store.keepTransfers == 0 {
+		return nil
+	}
+
+	// sequences grow by one, so the oldest keys are the ones beyond keepTransfers
+	expired := [][]byte{}
+	cursor := transfers.Cursor()
+	for key, _ := cursor.First(); key != nil && sequence-decodeUint64(key) >= store.keepTransfers; key, _ = cursor.Next() {
+		expired = append(expired, append([]byte{}, key...))
+	}
+
+	for _, key := range expired {
+		err = transfers.Delete(key)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Balance returns balance of address
+func (store *Store) Balance(token, address string) string {
+	balance := ""
+	store.db.View(func(tx *bolt.Tx) error {
+		if balances := tx.Bucket(balancesBucket).Bucket([]byte(token)); balances != nil {
+			balance = string(balances.Get([]byte(address)))
+		}
+		return nil
+	})
+	return parseAmount(balance).String()
+}
+
+// Holders returns addresses with non zero balance, the largest balance first
+func (store *Store) Holders(token string, limit int) []Holder {
+
+	holders := []Holder{}
+	store.db.View(func(tx *bolt.Tx) error {
+		balances := tx.Bucket(balancesBucket).Bucket([]byte(token))
+		if balances == nil {
+			return nil
+		}
+		return balances.ForEach(func(address, balance []byte) error {
+			holders = append(holders, Holder{Address: string(address), Balance: string(balance)})
+			return nil
+		})
+	})
+
+	sort.Slice(holders, func(i, j int) bool {
+		cmp := parseAmount(holders[i].Balance).Cmp(parseAmount(holders[j].Balance))
+		if cmp != 0 {
+			return cmp > 0
+		}
+		return holders[i].Address < holders[j].Address
+	})
+
+	if limit > 0 && len(holders) > limit {
+		holders = holders[:limit]
+	}
+
+	return holders
+}
+
+// Allowance returns allowance of spender on owner
+func (store *Store) Allowance(token, owner, spender string) string {
+	allowance := ""
+	store.db.View(func(tx *bolt.Tx) error {
+		if allowances := tx.Bucket(allowancesBucket).Bucket([]byte(token)); allowances != nil {
+			allowance = string(allowances.Get(allowanceKey(owner, spender)))
+		}
+		return nil
+	})
+	return parseAmount(allowance).String()
+}
+
+// Allowances returns every non zero allowance of owner by spender
+func (store *Store) Allowances(token, owner string) map[string]string {
+
+	allowances := map[string]string{}
+	store.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(allowancesBucket).Bucket([]byte(token))
+		if bucket == nil {
+			return nil
+		}
+
+		prefix := allowanceKey(owner, "")
+		cursor := bucket.Cursor()
+		for key, allowance := cursor.Seek(prefix); key != nil && bytes.HasPrefix(key, prefix); key, allowance = cursor.Next() {
+			allowances[string(key[len(prefix):])] = string(allowance)
+		}
+		return nil
+	})
+
+	return allowances
+}
+
+// Transfers returns the kept transfers of token, the latest first
+// if address is not empty, only transfers sent or received by address are returned
+func (store *Store) Transfers(token, address string, limit int) []Transfer {
+
+	transfers := []Transfer{}
+	store.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(transfersBucket).Bucket([]byte(token))
+		if bucket == nil {
+			return nil
+		}
+
+		cursor := bucket.Cursor()
+		for key, transferBytes := cursor.Last(); key != nil; key, transferBytes = cursor.Prev() {
+			if limit > 0 && len(transfers) == limit {
+				break
+			}
+
+			transfer := Transfer{}
+			err := json.Unmarshal(transferBytes, &transfer)
+			if err != nil {
+				return err
+			}
+
+			if address != "" && transfer.Sender != address && transfer.Recipient != address && transfer.Treasury != address {
+				continue
+			}
+			transfers = append(transfers, transfer)
+		}
+		return nil
+	})
+
+	return transfers
+}
+
+// allowanceKey is owner 0x00 spender, addresses never contain 0x00
+func allowanceKey(owner, spender string) []byte {
+	return []byte(owner + "\x00" + spender)
+}
+
+// encodeUint64 is big endian, so keys sort by number
+func encodeUint64(value uint64) []byte {
+	valueBytes := make([]byte, 8)
+	binary.BigEndian.PutUint64(valueBytes, value)
+	return valueBytes
+}
+
+func decodeUint64(valueBytes []byte) uint64 {
+	if len(valueBytes) != 8 {
+		return 0
+	}
+	return binary.BigEndian.Uint64(valueBytes)
+}
+
+// parseAmount returns 0 for an empty or invalid amount
+func parseAmount(value string) *big.Int {
+	amount, ok := new(big.Int).SetString(value, 10)
+	if !ok {
+		return new(big.Int)
+	}
+	return amount
+}
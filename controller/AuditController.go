@@ -0,0 +1,105 @@
+package controller
+
+import (
+	"encoding/json"
+	"hyperledger_dapp/model"
+	"hyperledger_dapp/repository"
+	"hyperledger_dapp/util"
+	"math/big"
+	"sort"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	sc "github.com/hyperledger/fabric-protos-go/peer"
+)
+
+// auditSupply is query fnc that checks the sum of every balance equals the total supply
+// only ADMIN can audit, it reads every balance of token
+// params - tokenName, number of largest holders
+// return - model.SupplyAudit
+func (cc *Controller) auditSupply(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	tokenName, largest := params[0], params[1]
+
+	largestInt, err := util.ConvertToPageSize("largest", largest)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	totalSupply, err := repository.GetERC20TotalSupply(stub, tokenName)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	balances, unparseable, err := repository.ListBalances(stub, tokenName)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	audit := auditSupply(tokenName, totalSupply, balances, unparseable, int(largestInt))
+
+	auditBytes, err := json.Marshal(audit)
+	if err != nil {
+		return shim.Error("failed to Marshal supply audit, error : " + err.Error())
+	}
+
+	return shim.Success(auditBytes)
+}
+
+// auditSupply makes the report of balances against totalSupply
+func auditSupply(tokenName string, totalSupply *big.Int, balances map[string]*big.Int, unparseable []model.UnparseableBalance, largest int) *model.SupplyAudit {
+
+	audit := &model.SupplyAudit{
+		Token:          tokenName,
+		TotalSupply:    totalSupply.String(),
+		LargestHolders: []model.Holder{},
+		Discrepancies:  []string{},
+		Unparseable:    unparseable,
+	}
+
+	balanceSum := new(big.Int)
+	holders := []model.Holder{}
+	for address, balance := range balances {
+		balanceSum.Add(balanceSum, balance)
+
+		switch balance.Sign() {
+		case 1:
+			holders = append(holders, model.Holder{Address: address, Balance: balance.String()})
+		case -1:
+			audit.Discrepancies = append(audit.Discrepancies, "negative balance "+balance.String()+" of "+address)
+		}
+	}
+
+	// the largest balance first, address breaks ties so the report is deterministic
+	sort.Slice(holders, func(i, j int) bool {
+		cmp := balances[holders[i].Address].Cmp(balances[holders[j].Address])
+		if cmp != 0 {
+			return cmp > 0
+		}
+		return holders[i].Address < holders[j].Address
+	})
+
+	if len(holders) > largest {
+		audit.LargestHolders = holders[:largest]
+	} else {
+		audit.LargestHolders = holders
+	}
+
+	difference := new(big.Int).Sub(balanceSum, totalSupply)
+	if difference.Sign() != 0 {
+		audit.Discrepancies = append(audit.Discrepancies, "sum of balances "+balanceSum.String()+" differs from total supply "+totalSupply.String()+" by "+difference.String())
+	}
+
+	for _, entry := range unparseable {
+		audit.Discrepancies = append(audit.Discrepancies, "unparseable balance "+entry.Value+" of "+entry.Address)
+	}
+
+	// balances are read from a map, so discrepancies are sorted for a deterministic report
+	sort.Strings(audit.Discrepancies)
+
+	audit.BalanceSum = balanceSum.String()
+	audit.Difference = difference.String()
+	audit.HolderCount = len(holders)
+	audit.Consistent = len(audit.Discrepancies) == 0
+
+	return audit
+}
@@ -0,0 +1,137 @@
+package controller
+
+import (
+	"encoding/json"
+	"hyperledger_dapp/identity"
+	"hyperledger_dapp/model"
+	"hyperledger_dapp/repository"
+	"hyperledger_dapp/util"
+	"math/big"
+	"strconv"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	sc "github.com/hyperledger/fabric-protos-go/peer"
+)
+
+// batchTransfer is invoke fnc that moves token from the caller to every recipient at once
+// every leg succeeds or the whole batch fails, fee applies to each leg like Transfer
+// params - token name, caller's address, recipient's address, amount, recipient's address, amount, ...
+func (cc *Controller) batchTransfer(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	tokenName, callerAddress := params[0], params[1]
+
+	legs := []model.TransferLeg{}
+	for i := 2; i < len(params); i += 2 {
+		legs = append(legs, model.TransferLeg{Recipient: params[i], Amount: params[i+1]})
+	}
+
+	// caller must be the submitter
+	err := identity.CheckCaller(stub, callerAddress)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	err = batchTransfer(stub, repository.BatchTransferEventKey, tokenName, callerAddress, legs)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	return shim.Success([]byte("batchTransfer success"))
+}
+
+// airdrop is invoke fnc that moves token from the caller to every recipient at once
+// only ADMIN can airdrop
+// params - token name, recipients(JSON list of {"recipient", "amount"})
+func (cc *Controller) airdrop(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	tokenName := params[0]
+
+	callerAddress, err := identity.GetCallerAddress(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	legs := []model.TransferLeg{}
+	err = json.Unmarshal([]byte(params[1]), &legs)
+	if err != nil {
+		return shim.Error("failed to Unmarshal recipients, error : " + err.Error())
+	}
+
+	err = batchTransfer(stub, repository.AirdropEventKey, tokenName, callerAddress, legs)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	return shim.Success([]byte("airdrop success"))
+}
+
+// batchTransfer moves token of every leg from sender and emits one event describing every leg
+// the total is checked against the sender balance once
+func batchTransfer(stub shim.ChaincodeStubInterface, eventKey, tokenName, senderAddress string, legs []model.TransferLeg) error {
+
+	if len(legs) == 0 || len(legs) > util.MaxBatchSize {
+		return model.NewCustomError(model.ConvertErrorType, "batch", "must have 1 to "+strconv.Itoa(util.MaxBatchSize)+" transfers")
+	}
+
+	addresses := []string{senderAddress}
+	for _, leg := range legs {
+		addresses = append(addresses, leg.Recipient)
+	}
+
+	// frozen accounts cannot send or receive
+	err := requireNotFrozen(stub, addresses...)
+	if err != nil {
+		return err
+	}
+
+	// legs to the same address are summed, so every balance is saved once
+	changes := balanceChanges{}
+	total := new(big.Int)
+	treasury := ""
+	for i, leg := range legs {
+		amount, err := util.ConvertToPositive("transfer amount", leg.Amount)
+		if err != nil {
+			return err
+		}
+
+		fee, treasuryAddress, err := quoteFee(stub, tokenName, senderAddress, leg.Recipient, amount)
+		if err != nil {
+			return err
+		}
+
+		changes.add(senderAddress, new(big.Int).Neg(amount))
+		changes.add(leg.Recipient, new(big.Int).Sub(amount, fee))
+		if fee.Sign() > 0 {
+			changes.add(treasuryAddress, fee)
+			legs[i].Fee = fee.String()
+			treasury = treasuryAddress
+		}
+
+		total.Add(total, amount)
+		legs[i].Amount = amount.String()
+	}
+
+	balance, err := repository.GetBalance(stub, tokenName, senderAddress, true)
+	if err != nil {
+		return err
+	}
+
+	if balance.Cmp(total) < 0 {
+		return model.NewCustomError(model.UnderflowErrorType, "balance", "total "+total.String()+" exceeds balance "+balance.String())
+	}
+
+	// treasury is checked like any recipient once a leg pays a fee
+	if treasury != "" {
+		err = requireNotFrozen(stub, treasury)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = changes.apply(stub, tokenName)
+	if err != nil {
+		return err
+	}
+
+	return repository.EmitBatchTransferEvent(stub, eventKey, model.NewBatchTransferEvent(tokenName, senderAddress, total, treasury, legs))
+}
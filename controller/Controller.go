@@ -1,7 +1,10 @@
 package controller
 
 import (
+	"hyperledger_dapp/identity"
+	"hyperledger_dapp/model"
 	"hyperledger_dapp/repository"
+	"hyperledger_dapp/util"
 	"strconv"
 
 	"github.com/hyperledger/fabric-chaincode-go/shim"
@@ -14,35 +17,135 @@ func NewContoller() *Controller {
 	return &Controller{}
 }
 
+// EmitEvents runs fnc with an event collector
+// events set by fnc are emitted as one envelope when it succeeds
+func EmitEvents(stub shim.ChaincodeStubInterface, fnc func(stub shim.ChaincodeStubInterface) sc.Response) sc.Response {
+
+	collector := repository.NewEventCollector(stub)
+	res := fnc(collector)
+	if res.Status >= shim.ERRORTHRESHOLD {
+		return res
+	}
+
+	err := collector.Flush()
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	return res
+}
+
+// newRecordID returns the id of a record created by the transaction
+// tx id is unique, so a fnc creating one record per transaction uses it as the id
+func newRecordID(stub shim.ChaincodeStubInterface) string {
+	return stub.GetTxID()
+}
+
 func (cc *Controller) Init(stub shim.ChaincodeStubInterface, params []string) sc.Response {
 
-	if len(params) != 4 {
+	// cap is optional
+	if len(params) != 5 && len(params) != 6 {
 		return shim.Error("incorrect number of parameter")
 	}
 
-	tokenName, symbol, owner, amount := params[0], params[1], params[2], params[3]
+	tokenName, symbol, owner, amount, decimals := params[0], params[1], params[2], params[3], params[4]
+	capAmount := ""
+	if len(params) == 6 {
+		capAmount = params[5]
+	}
+
+	// owner of the first token is the first admin, after that only ADMIN can init a token
+	tokens, err := repository.ListERC20Metadata(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	if len(tokens) > 0 {
+		_, err = requireRole(stub, "", model.AdminRole)
+		if err != nil {
+			return forbidden(err)
+		}
+	}
+
+	err = createToken(stub, tokenName, symbol, owner, amount, decimals, capAmount)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	if len(tokens) == 0 {
+		err = repository.GrantRole(stub, "", model.AdminRole, owner)
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+	}
+
+	return shim.Success(nil)
+}
+
+// createToken registers token metadata and assigns the initial supply to owner
+// owner is the first MINTER and BURNER of token, ADMIN grants them to others with grantRole
+// empty cap means the token is not capped
+func createToken(stub shim.ChaincodeStubInterface, tokenName, symbol, owner, amount, decimals, capAmount string) error {
 
 	// check amount is unsigned int
-	amountUint, err := strconv.ParseUint(string(amount), 10, 64)
+	amountInt, err := util.ConvertToAmount("amount", amount)
 	if err != nil {
-		return shim.Error("amount must be a number or amount cannot be negative")
+		return err
+	}
+
+	// check decimals is uint8
+	decimalsUint, err := strconv.ParseUint(decimals, 10, 8)
+	if err != nil {
+		return model.NewCustomError(model.ConvertErrorType, "decimals", "decimals must be a number between 0 and 255")
 	}
 
 	// tokenName & symbol & owner cannot be empty
 	if len(tokenName) == 0 || len(symbol) == 0 || len(owner) == 0 {
-		return shim.Error("tokenName or symbol or owner cannot be emtpy")
+		return model.NewCustomError(model.ConvertErrorType, "metadata", "tokenName or symbol or owner cannot be emtpy")
 	}
 
-	err = repository.SaveERC20Metadata(stub, tokenName, symbol, owner, uint(amountUint))
+	// token name is the token ID, it cannot be registered twice
+	exists, err := repository.ExistsERC20Metadata(stub, tokenName)
 	if err != nil {
-		return shim.Error(err.Error())
+		return err
+	}
+
+	if exists {
+		return model.NewCustomError(model.PutStateErrorType, "metadata", tokenName+" already exists")
+	}
+
+	metadata := model.NewERC20Metadata(tokenName, symbol, owner, uint8(decimalsUint), amountInt)
+
+	// initial supply cannot exceed cap
+	if capAmount != "" {
+		capInt, err := util.ConvertToPositive("cap", capAmount)
+		if err != nil {
+			return err
+		}
+
+		if amountInt.Cmp(capInt) > 0 {
+			return model.NewCustomError(model.OverflowErrorType, "totalSupply", "initial supply exceeds cap "+capAmount)
+		}
+		metadata.SetCap(capInt)
+	}
+	err = repository.SaveERC20Metadata(stub, metadata)
+	if err != nil {
+		return err
 	}
 
 	// save owner balance
-	err = repository.SaveBalance(stub, owner, amount)
+	err = repository.SaveBalance(stub, tokenName, owner, amountInt)
 	if err != nil {
-		return shim.Error(err.Error())
+		return err
 	}
 
-	return shim.Success(nil)
+	for _, role := range model.TokenRoles {
+		err = repository.GrantRole(stub, tokenName, role, owner)
+		if err != nil {
+			return err
+		}
+	}
+
+	// initial supply is minted to owner
+	return repository.EmitTransferEvent(stub, tokenName, identity.ZeroAddress, owner, amountInt)
 }
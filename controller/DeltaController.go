@@ -0,0 +1,81 @@
+package controller
+
+import (
+	"encoding/json"
+	"hyperledger_dapp/repository"
+	"strconv"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	sc "github.com/hyperledger/fabric-protos-go/peer"
+)
+
+// setDeltaMode is invoke fnc that switches how credits of address are saved
+// in delta mode each credit is a key of its own transaction, so concurrent transfers to a busy address
+// do not fail with MVCC read conflicts, debits and compactBalance fold the deltas into the base value
+// voting power of a delegated address still changes by read and write
+// only ADMIN can set delta mode
+// params - token name, address, enabled(true or false)
+func (cc *Controller) setDeltaMode(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	tokenName, address := params[0], params[1]
+
+	enabled, err := strconv.ParseBool(params[2])
+	if err != nil {
+		return shim.Error("enabled must be true or false")
+	}
+
+	// base value is saved, so the address is listed among balances before the first debit
+	err = compactBalance(stub, tokenName, address)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	err = repository.SaveDeltaMode(stub, tokenName, address, enabled)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	return shim.Success([]byte("setDeltaMode success"))
+}
+
+// deltaMode is query fnc
+// params - token name, address
+// return - true if credits of address are saved as deltas
+func (cc *Controller) deltaMode(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	deltaMode, err := repository.IsDeltaMode(stub, params[0], params[1])
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	deltaModeBytes, err := json.Marshal(deltaMode)
+	if err != nil {
+		return shim.Error("failed to Marshal deltaMode, error : " + err.Error())
+	}
+
+	return shim.Success(deltaModeBytes)
+}
+
+// compactBalance is invoke fnc that folds the deltas of address into its base value
+// the balance does not change, so anyone can submit it
+// params - token name, address
+func (cc *Controller) compactBalance(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	err := compactBalance(stub, params[0], params[1])
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	return shim.Success([]byte("compactBalance success"))
+}
+
+// compactBalance saves the balance including deltas as the base value
+func compactBalance(stub shim.ChaincodeStubInterface, tokenName, address string) error {
+
+	balance, err := repository.GetBalance(stub, tokenName, address, true)
+	if err != nil {
+		return err
+	}
+
+	return repository.SaveBalance(stub, tokenName, address, balance)
+}
@@ -0,0 +1,422 @@
+package controller
+
+import (
+	"encoding/json"
+	"hyperledger_dapp/identity"
+	"hyperledger_dapp/model"
+	"hyperledger_dapp/repository"
+	"hyperledger_dapp/util"
+	"math/big"
+	"strconv"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	sc "github.com/hyperledger/fabric-protos-go/peer"
+)
+
+// dividendEscrow is the account holding the deposits of distributions
+var dividendEscrow = identity.EscrowAddress("dividend")
+
+// unclaimableAddresses are the accounts nobody can submit a claim for
+// their shares are paid back to payer by reclaimDividend
+// other holders without Fabric identity, such as accounts of a signing key only, cannot claim either,
+// their shares are reclaimed after the deadline, which payer sets with setDividendDeadline if there is none
+var unclaimableAddresses = []string{identity.ZeroAddress, htlcEscrow, vestingEscrow, dividendEscrow, governanceAddress}
+
+// createDistribution is invoke fnc that deposits amount payout token for holders of token at snapshot
+// only ADMIN can create distribution, and the admin is the payer
+// payout token is a token of this chaincode, the deposit moves into escrow so every share is funded
+// a token of another chaincode cannot be paid out: a chaincode called through stub.InvokeChaincode
+// only sees the proposal, not which chaincode calls it, so no account held there could be withdrawn safely
+// params - token name, snapshot id, payout token name, payer's address, amount, [deadline(unix seconds, 0 for none)]
+// return - distribution id
+func (cc *Controller) createDistribution(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	tokenName, payoutToken, payerAddress, amount := params[0], params[2], params[3], params[4]
+
+	amountInt, err := util.ConvertToPositive("distribution amount", amount)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	timestamp, err := stub.GetTxTimestamp()
+	if err != nil {
+		return shim.Error("failed to GetTxTimestamp, error : " + err.Error())
+	}
+
+	var deadline int64
+	if len(params) > 5 {
+		deadline, err = strconv.ParseInt(params[5], 10, 64)
+		if err != nil || deadline < 0 {
+			return shim.Error("deadline must be unix seconds")
+		}
+
+		if deadline > 0 && deadline <= timestamp.GetSeconds() {
+			return shim.Error("deadline must be in the future")
+		}
+	}
+
+	// payer must be the submitter
+	err = identity.CheckCaller(stub, payerAddress)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	snapshotID, err := convertToSnapshotID(stub, tokenName, params[1])
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	totalSupply, err := repository.GetTotalSupplyAt(stub, tokenName, snapshotID)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	if totalSupply.Sign() == 0 {
+		return shim.Error("total supply at snapshot is zero")
+	}
+
+	err = requireNotFrozen(stub, payerAddress)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	distribution := model.NewDistribution(newRecordID(stub), tokenName, snapshotID, payoutToken, payerAddress, amountInt, totalSupply, deadline)
+
+	err = transfer(stub, payoutToken, payerAddress, dividendEscrow, amountInt)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	err = repository.SaveDistribution(stub, distribution)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	return shim.Success([]byte(distribution.ID))
+}
+
+// claimDividend is invoke fnc that pays the share of holder once, holder must be the submitter
+// the share cannot be claimed after the deadline
+// params - distribution id, holder's address
+// return - paid share
+func (cc *Controller) claimDividend(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	id, holderAddress := params[0], params[1]
+
+	distribution, err := repository.GetDistribution(stub, id)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	timestamp, err := stub.GetTxTimestamp()
+	if err != nil {
+		return shim.Error("failed to GetTxTimestamp, error : " + err.Error())
+	}
+
+	if distribution.IsExpired(timestamp.GetSeconds()) {
+		return shim.Error("distribution " + id + " is expired")
+	}
+
+	// share is claimed once
+	claimed, err := repository.IsDividendClaimed(stub, id, holderAddress)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	if claimed {
+		return shim.Error(holderAddress + " already claimed distribution " + id)
+	}
+
+	balance, err := repository.GetBalanceAt(stub, distribution.Token, holderAddress, distribution.SnapshotID)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	if balance.Sign() == 0 {
+		return shim.Error(holderAddress + " has no balance at snapshot")
+	}
+
+	share, err := distribution.ShareOf(balance)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	err = payDividend(stub, distribution, holderAddress, share)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// the claim is the only write, so holders claim concurrently
+	err = repository.SaveDividendClaim(stub, id, holderAddress, model.NewDividendClaim(share, balance, false))
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	return shim.Success([]byte(share.String()))
+}
+
+// setDividendDeadline is invoke fnc that sets the deadline of a distribution which has none
+// the deadline is at least DividendNoticePeriod away, so holders keep time to claim before payer reclaims their shares
+// params - distribution id, deadline(unix seconds)
+func (cc *Controller) setDividendDeadline(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	id := params[0]
+
+	distribution, err := repository.GetDistribution(stub, id)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// payer must be the submitter
+	err = identity.CheckCaller(stub, distribution.Payer)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	if distribution.Deadline > 0 {
+		return shim.Error("distribution " + id + " already has a deadline")
+	}
+
+	deadline, err := strconv.ParseInt(params[1], 10, 64)
+	if err != nil {
+		return shim.Error("deadline must be unix seconds")
+	}
+
+	timestamp, err := stub.GetTxTimestamp()
+	if err != nil {
+		return shim.Error("failed to GetTxTimestamp, error : " + err.Error())
+	}
+
+	if deadline < timestamp.GetSeconds()+model.DividendNoticePeriod {
+		return shim.Error("deadline must be at least " + strconv.Itoa(model.DividendNoticePeriod) + " seconds away")
+	}
+
+	distribution.Deadline = deadline
+
+	err = repository.SaveDistribution(stub, distribution)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	return shim.Success([]byte("setDividendDeadline success"))
+}
+
+// reclaimDividend is invoke fnc that pays back to payer what no holder can claim
+// the shares of unclaimable addresses are reclaimed once, the rounding dust is reclaimed as it accrues
+// after the deadline every unclaimed share is reclaimed
+// params - distribution id
+// return - reclaimed amount
+func (cc *Controller) reclaimDividend(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	id := params[0]
+
+	distribution, err := repository.GetDistribution(stub, id)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// payer must be the submitter
+	err = identity.CheckCaller(stub, distribution.Payer)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	err = requireNotFrozen(stub, distribution.Payer)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// fabric does not read its own writes, so claims saved below are added to the listed ones
+	claims, err := repository.ListDividendClaims(stub, id)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	for _, address := range unclaimableAddresses {
+		claimed, err := repository.IsDividendClaimed(stub, id, address)
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+
+		if claimed {
+			continue
+		}
+
+		balance, err := repository.GetBalanceAt(stub, distribution.Token, address, distribution.SnapshotID)
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+
+		if balance.Sign() == 0 {
+			continue
+		}
+
+		share, err := distribution.ShareOf(balance)
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+
+		claim := model.NewDividendClaim(share, balance, true)
+
+		err = repository.SaveDividendClaim(stub, id, address, claim)
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+
+		claims = append(claims, claim)
+	}
+
+	timestamp, err := stub.GetTxTimestamp()
+	if err != nil {
+		return shim.Error("failed to GetTxTimestamp, error : " + err.Error())
+	}
+
+	// after the deadline nothing is claimable, so every unclaimed share counts as dust
+	if distribution.IsExpired(timestamp.GetSeconds()) {
+		distribution.Closed = true
+	}
+
+	// dust is counted after the shares above are recorded
+	claimed, claimedSupply, err := model.SumClaims(claims)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	reclaimed, err := distribution.Dust(claimed, claimedSupply)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	if reclaimed.Sign() == 0 {
+		return shim.Error("distribution " + id + " has nothing to reclaim")
+	}
+
+	err = transfer(stub, distribution.PayoutToken, dividendEscrow, distribution.Payer, reclaimed)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	curReclaimed, err := distribution.GetReclaimed()
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	distribution.Reclaimed = new(big.Int).Add(curReclaimed, reclaimed).String()
+
+	err = repository.SaveDistribution(stub, distribution)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	return shim.Success([]byte(reclaimed.String()))
+}
+
+// unclaimedDividend is query fnc
+// params - distribution id, holder's address
+// return - share holder can claim, 0 if it is claimed or the distribution is expired
+func (cc *Controller) unclaimedDividend(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	id, holderAddress := params[0], params[1]
+
+	distribution, err := repository.GetDistribution(stub, id)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	timestamp, err := stub.GetTxTimestamp()
+	if err != nil {
+		return shim.Error("failed to GetTxTimestamp, error : " + err.Error())
+	}
+
+	if distribution.IsExpired(timestamp.GetSeconds()) {
+		return shim.Success([]byte("0"))
+	}
+
+	claimed, err := repository.IsDividendClaimed(stub, id, holderAddress)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	if claimed {
+		return shim.Success([]byte("0"))
+	}
+
+	balance, err := repository.GetBalanceAt(stub, distribution.Token, holderAddress, distribution.SnapshotID)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	share, err := distribution.ShareOf(balance)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	return shim.Success([]byte(share.String()))
+}
+
+// distributionInfo is query fnc
+// params - distribution id
+// return - distribution with the amounts summed from its claims
+func (cc *Controller) distributionInfo(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	distribution, err := repository.GetDistribution(stub, params[0])
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	claims, err := repository.ListDividendClaims(stub, distribution.ID)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	claimed, claimedSupply, err := model.SumClaims(claims)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	unclaimed, err := distribution.Unclaimed(claimedSupply)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	dust, err := distribution.Dust(claimed, claimedSupply)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	distributionInfo := model.DistributionInfo{
+		Distribution:  *distribution,
+		Claimed:       claimed.String(),
+		ClaimedSupply: claimedSupply.String(),
+		Unclaimed:     unclaimed.String(),
+		Dust:          dust.String(),
+	}
+
+	distributionInfoBytes, err := json.Marshal(distributionInfo)
+	if err != nil {
+		return shim.Error("failed to Marshal distributionInfo, error : " + err.Error())
+	}
+
+	return shim.Success(distributionInfoBytes)
+}
+
+// payDividend pays share from escrow, holder must be the submitter
+func payDividend(stub shim.ChaincodeStubInterface, distribution *model.Distribution, holderAddress string, share *big.Int) error {
+
+	err := identity.CheckCaller(stub, holderAddress)
+	if err != nil {
+		return err
+	}
+
+	err = requireNotFrozen(stub, holderAddress)
+	if err != nil {
+		return err
+	}
+
+	// rounded down share can be zero, the claim is still recorded
+	if share.Sign() == 0 {
+		return nil
+	}
+
+	return transfer(stub, distribution.PayoutToken, dividendEscrow, holderAddress, share)
+}
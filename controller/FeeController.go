@@ -0,0 +1,228 @@
+package controller
+
+import (
+	"encoding/json"
+	"hyperledger_dapp/model"
+	"hyperledger_dapp/repository"
+	"hyperledger_dapp/util"
+	"math/big"
+	"strconv"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	sc "github.com/hyperledger/fabric-protos-go/peer"
+)
+
+// setFeeConfig is invoke fnc that sets the transfer fee of token
+// the fee is charged on transfer and transferFrom, signed or not, batchTransfer and airdrop only,
+// HTLC locks, vesting schedules and dividends move tokens into and out of escrow without fee
+// only ADMIN can set fee
+// params - token name, basis points, minimum fee, maximum fee(0 is no maximum), treasury's address
+func (cc *Controller) setFeeConfig(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	tokenName, treasuryAddress := params[0], params[4]
+
+	basisPoints, err := strconv.ParseInt(params[1], 10, 64)
+	if err != nil || basisPoints < 0 || basisPoints > model.BasisPoints {
+		return shim.Error("basis points must be between 0 and 10000")
+	}
+
+	minimum, err := util.ConvertToAmount("minimum fee", params[2])
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	maximum, err := util.ConvertToAmount("maximum fee", params[3])
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	if maximum.Sign() > 0 && minimum.Cmp(maximum) > 0 {
+		return shim.Error("minimum fee cannot be more than maximum fee")
+	}
+
+	if treasuryAddress == "" {
+		return shim.Error("treasury address is empty")
+	}
+
+	_, err = repository.GetERC20Metadata(stub, tokenName)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	err = repository.SaveFeeConfig(stub, model.NewFeeConfig(tokenName, basisPoints, minimum, maximum, treasuryAddress))
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	return shim.Success([]byte("setFeeConfig success"))
+}
+
+// setFeeExempt is invoke fnc that adds or removes address from the fee exempt list of token
+// transfers from or to an exempt address pay no fee
+// only ADMIN can set fee exempt
+// params - token name, address, exempt(true or false)
+func (cc *Controller) setFeeExempt(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	tokenName, address := params[0], params[1]
+
+	exempt, err := strconv.ParseBool(params[2])
+	if err != nil {
+		return shim.Error("exempt must be true or false")
+	}
+
+	_, err = repository.GetERC20Metadata(stub, tokenName)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	err = repository.SaveFeeExempt(stub, tokenName, address, exempt)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	return shim.Success([]byte("setFeeExempt success"))
+}
+
+// feeConfig is query fnc
+// params - token name
+// return - fee config of token, null if token charges no fee
+func (cc *Controller) feeConfig(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	config, err := repository.GetFeeConfig(stub, params[0])
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	configBytes, err := json.Marshal(config)
+	if err != nil {
+		return shim.Error("failed to Marshal config, error : " + err.Error())
+	}
+
+	return shim.Success(configBytes)
+}
+
+// listFeeExempt is query fnc
+// params - token name
+// return - fee exempt addresses of token
+func (cc *Controller) listFeeExempt(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	addresses, err := repository.ListFeeExempt(stub, params[0])
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	addressesBytes, err := json.Marshal(addresses)
+	if err != nil {
+		return shim.Error("failed to Marshal addresses, error : " + err.Error())
+	}
+
+	return shim.Success(addressesBytes)
+}
+
+// quoteFee is query fnc
+// params - token name, amount, sender's address, recipient's address
+// return - fee of the transfer and the amount recipient receives
+func (cc *Controller) quoteFee(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	tokenName, senderAddress, recipientAddress := params[0], params[2], params[3]
+
+	amount, err := util.ConvertToPositive("transfer amount", params[1])
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	fee, treasuryAddress, err := quoteFee(stub, tokenName, senderAddress, recipientAddress, amount)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	feeQuote := model.FeeQuote{
+		Amount:   amount.String(),
+		Fee:      fee.String(),
+		Received: new(big.Int).Sub(amount, fee).String(),
+		Treasury: treasuryAddress,
+	}
+
+	feeQuoteBytes, err := json.Marshal(feeQuote)
+	if err != nil {
+		return shim.Error("failed to Marshal feeQuote, error : " + err.Error())
+	}
+
+	return shim.Success(feeQuoteBytes)
+}
+
+// quoteFee returns the fee of transfer and the treasury receiving it
+// transfers from or to an exempt address or the treasury pay no fee
+func quoteFee(stub shim.ChaincodeStubInterface, tokenName, senderAddress, recipientAddress string, amount *big.Int) (*big.Int, string, error) {
+
+	config, err := repository.GetFeeConfig(stub, tokenName)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if config == nil || senderAddress == config.Treasury || recipientAddress == config.Treasury {
+		return new(big.Int), "", nil
+	}
+
+	for _, address := range []string{senderAddress, recipientAddress} {
+		exempt, err := repository.IsFeeExempt(stub, tokenName, address)
+		if err != nil {
+			return nil, "", err
+		}
+
+		if exempt {
+			return new(big.Int), "", nil
+		}
+	}
+
+	fee, err := config.FeeOf(amount)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return fee, config.Treasury, nil
+}
+
+// transferWithFee moves amount token from sender, recipient receives amount - fee and treasury receives fee
+func transferWithFee(stub shim.ChaincodeStubInterface, tokenName, senderAddress, recipientAddress string, amount *big.Int) error {
+
+	fee, treasuryAddress, err := quoteFee(stub, tokenName, senderAddress, recipientAddress, amount)
+	if err != nil {
+		return err
+	}
+
+	if fee.Sign() == 0 {
+		return transfer(stub, tokenName, senderAddress, recipientAddress, amount)
+	}
+
+	// treasury is checked like any recipient, a frozen treasury blocks transfers which pay a fee
+	err = requireNotFrozen(stub, treasuryAddress)
+	if err != nil {
+		return err
+	}
+
+	// self transfer only pays fee, so the balance is checked against amount
+	if senderAddress == recipientAddress {
+		senderAmount, err := repository.GetBalance(stub, tokenName, senderAddress, true)
+		if err != nil {
+			return err
+		}
+
+		_, err = util.SubAmount("balance", senderAmount, amount)
+		if err != nil {
+			return err
+		}
+	}
+
+	changes := balanceChanges{}
+	changes.add(senderAddress, new(big.Int).Neg(amount))
+	changes.add(recipientAddress, new(big.Int).Sub(amount, fee))
+	changes.add(treasuryAddress, fee)
+
+	err = changes.apply(stub, tokenName)
+	if err != nil {
+		return err
+	}
+
+	return repository.EmitTransferFeeEvent(stub, tokenName, senderAddress, recipientAddress, amount, fee, treasuryAddress)
+}
@@ -0,0 +1,130 @@
+package controller
+
+import (
+	"encoding/json"
+	"hyperledger_dapp/identity"
+	"hyperledger_dapp/model"
+	"hyperledger_dapp/repository"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	sc "github.com/hyperledger/fabric-protos-go/peer"
+)
+
+// freezeAccount is invoke fnc that blocks address from sending, receiving and approving tokens
+// only ADMIN can freeze account
+// params - address
+func (cc *Controller) freezeAccount(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	address := params[0]
+
+	callerAddress, err := identity.GetCallerAddress(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	timestamp, err := stub.GetTxTimestamp()
+	if err != nil {
+		return shim.Error("failed to GetTxTimestamp, error : " + err.Error())
+	}
+
+	record := model.NewFreezeRecord(address, callerAddress, stub.GetTxID(), timestamp.GetSeconds())
+	err = repository.SaveFreezeRecord(stub, record)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	err = repository.EmitFreezeEvent(stub, repository.FreezeEventKey, address, callerAddress)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	return shim.Success([]byte("freezeAccount success"))
+}
+
+// unfreezeAccount is invoke fnc that removes the freeze record of address
+// only ADMIN can unfreeze account, and address must be frozen
+// params - address
+func (cc *Controller) unfreezeAccount(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	address := params[0]
+
+	callerAddress, err := identity.GetCallerAddress(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	frozen, err := repository.IsFrozen(stub, address)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	if !frozen {
+		return shim.Error(address + " is not frozen")
+	}
+
+	err = repository.DeleteFreezeRecord(stub, address)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	err = repository.EmitFreezeEvent(stub, repository.UnfreezeEventKey, address, callerAddress)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	return shim.Success([]byte("unfreezeAccount success"))
+}
+
+// isFrozen is query fnc
+// params - address
+// return - true if address is frozen
+func (cc *Controller) isFrozen(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	address := params[0]
+
+	frozen, err := repository.IsFrozen(stub, address)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	frozenBytes, err := json.Marshal(frozen)
+	if err != nil {
+		return shim.Error("failed to Marshal frozen, error : " + err.Error())
+	}
+
+	return shim.Success(frozenBytes)
+}
+
+// listFrozen is query fnc
+// return - freeze records of every frozen address
+func (cc *Controller) listFrozen(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	records, err := repository.ListFreezeRecords(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	recordsBytes, err := json.Marshal(records)
+	if err != nil {
+		return shim.Error("failed to Marshal records, error : " + err.Error())
+	}
+
+	return shim.Success(recordsBytes)
+}
+
+// requireNotFrozen returns frozen error if one of addresses is frozen
+func requireNotFrozen(stub shim.ChaincodeStubInterface, addresses ...string) error {
+
+	for _, address := range addresses {
+		frozen, err := repository.IsFrozen(stub, address)
+		if err != nil {
+			return err
+		}
+
+		if frozen {
+			return model.NewCustomError(model.FrozenErrorType, address, "account is frozen")
+		}
+	}
+
+	return nil
+}
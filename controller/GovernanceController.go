@@ -0,0 +1,504 @@
+package controller
+
+import (
+	"encoding/json"
+	"hyperledger_dapp/identity"
+	"hyperledger_dapp/model"
+	"hyperledger_dapp/repository"
+	"hyperledger_dapp/util"
+	"math/big"
+	"strconv"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	sc "github.com/hyperledger/fabric-protos-go/peer"
+)
+
+// governanceAddress is the account recorded as the actor of executed proposals
+var governanceAddress = identity.EscrowAddress("governance")
+
+// proposalActionParams is the number of params of each admin function a proposal can run
+// mint and setGovernanceConfig take the token of the proposal as the first param,
+// grantRole and revokeRole take it as the third param for a role of token
+var proposalActionParams = map[string]int{
+	"mint":                3,
+	"setGovernanceConfig": 4,
+	"pause":               0,
+	"unpause":             0,
+	"grantRole":           2,
+	"revokeRole":          2,
+}
+
+// proposalActionOptional is the number of optional params of admin function
+var proposalActionOptional = map[string]int{
+	"grantRole":  1,
+	"revokeRole": 1,
+}
+
+// setGovernanceConfig is invoke fnc that sets quorum, threshold and proposal threshold of token
+// only ADMIN can set config
+// params - token name, quorum(basis points), threshold(basis points), proposal threshold(basis points)
+func (cc *Controller) setGovernanceConfig(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	err := setGovernanceConfig(stub, params)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	return shim.Success([]byte("setGovernanceConfig success"))
+}
+
+// governanceConfig is query fnc
+// params - token name
+// return - quorum and threshold of token
+func (cc *Controller) governanceConfig(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	config, err := repository.GetGovernanceConfig(stub, params[0])
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	configBytes, err := json.Marshal(config)
+	if err != nil {
+		return shim.Error("failed to Marshal config, error : " + err.Error())
+	}
+
+	return shim.Success(configBytes)
+}
+
+// setGovernanceToken is invoke fnc that designates the token whose proposals can pause, unpause and change roles
+// only ADMIN can set governance token
+// params - token name
+func (cc *Controller) setGovernanceToken(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	tokenName := params[0]
+
+	_, err := repository.GetERC20Metadata(stub, tokenName)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	err = repository.SaveGovernanceToken(stub, tokenName)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	return shim.Success([]byte("setGovernanceToken success"))
+}
+
+// governanceToken is query fnc
+// return - the governance token, empty if none is designated
+func (cc *Controller) governanceToken(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	tokenName, err := repository.GetGovernanceToken(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	return shim.Success([]byte(tokenName))
+}
+
+// propose is invoke fnc that opens a proposal voted with balances at a snapshot taken now
+// the proposer must hold the proposal threshold, so not every holder can take snapshots
+// params - token name, proposer's address, description, actions(JSON list of model.ProposalAction), voting period(seconds)
+// return - proposal id
+func (cc *Controller) propose(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	tokenName, proposerAddress, description, actionsJSON := params[0], params[1], params[2], params[3]
+
+	votingPeriod, err := strconv.ParseInt(params[4], 10, 64)
+	if err != nil || votingPeriod <= 0 {
+		return shim.Error("voting period must be positive seconds")
+	}
+
+	actions := []model.ProposalAction{}
+	err = json.Unmarshal([]byte(actionsJSON), &actions)
+	if err != nil {
+		return shim.Error("failed to Unmarshal actions, error : " + err.Error())
+	}
+
+	err = checkProposalActions(stub, tokenName, actions)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// proposer must be the submitter
+	err = identity.CheckCaller(stub, proposerAddress)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	totalSupply, err := repository.GetERC20TotalSupply(stub, tokenName)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	config, err := repository.GetGovernanceConfig(stub, tokenName)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	balance, err := repository.GetBalance(stub, tokenName, proposerAddress, true)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	if !config.CanPropose(balance, totalSupply) {
+		return forbidden(model.NewCustomError(model.AuthorizeErrorType, "proposal", proposerAddress+" holds less than the proposal threshold"))
+	}
+
+	// voting power is the balance at this snapshot
+	snapshotID, err := takeSnapshot(stub, tokenName)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	timestamp, err := stub.GetTxTimestamp()
+	if err != nil {
+		return shim.Error("failed to GetTxTimestamp, error : " + err.Error())
+	}
+
+	proposal := model.NewProposal(newRecordID(stub), tokenName, proposerAddress, description, actions,
+		snapshotID, timestamp.GetSeconds(), timestamp.GetSeconds()+votingPeriod, config)
+
+	err = repository.SaveProposal(stub, proposal)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	return shim.Success([]byte(proposal.ID))
+}
+
+// castVote is invoke fnc that votes on an active proposal with the balance at its snapshot
+// params - proposal id, voter's address, support(for, against or abstain)
+func (cc *Controller) castVote(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	proposalID, voterAddress, support := params[0], params[1], params[2]
+
+	if support != model.VoteFor && support != model.VoteAgainst && support != model.VoteAbstain {
+		return shim.Error("support must be for, against or abstain")
+	}
+
+	// voter must be the submitter
+	err := identity.CheckCaller(stub, voterAddress)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	proposal, err := repository.GetProposal(stub, proposalID)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	timestamp, err := stub.GetTxTimestamp()
+	if err != nil {
+		return shim.Error("failed to GetTxTimestamp, error : " + err.Error())
+	}
+
+	if proposal.State != model.ProposalActive || timestamp.GetSeconds() >= proposal.End {
+		return shim.Error("voting of proposal " + proposalID + " is closed")
+	}
+
+	voted, err := repository.HasVoted(stub, proposalID, voterAddress)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	if voted {
+		return shim.Error(voterAddress + " already voted on proposal " + proposalID)
+	}
+
+	weight, err := repository.GetBalanceAt(stub, proposal.Token, voterAddress, proposal.SnapshotID)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	if weight.Sign() == 0 {
+		return shim.Error(voterAddress + " has no voting power at snapshot")
+	}
+
+	err = repository.SaveVote(stub, proposalID, model.NewVote(voterAddress, support, weight))
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	return shim.Success([]byte(weight.String()))
+}
+
+// tallyProposal is invoke fnc that counts the votes after voting period
+// anyone can submit it
+// params - proposal id
+// return - state of proposal, SUCCEEDED or DEFEATED
+func (cc *Controller) tallyProposal(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	proposalID := params[0]
+
+	proposal, err := repository.GetProposal(stub, proposalID)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	if proposal.State != model.ProposalActive {
+		return shim.Error("proposal " + proposalID + " is already " + proposal.State)
+	}
+
+	timestamp, err := stub.GetTxTimestamp()
+	if err != nil {
+		return shim.Error("failed to GetTxTimestamp, error : " + err.Error())
+	}
+
+	if timestamp.GetSeconds() < proposal.End {
+		return shim.Error("voting of proposal " + proposalID + " is not ended")
+	}
+
+	votes, err := repository.ListVotes(stub, proposalID)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	forVotes, againstVotes, abstainVotes := new(big.Int), new(big.Int), new(big.Int)
+	for _, vote := range votes {
+		weight, err := vote.GetWeight()
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+
+		switch vote.Support {
+		case model.VoteFor:
+			forVotes.Add(forVotes, weight)
+		case model.VoteAgainst:
+			againstVotes.Add(againstVotes, weight)
+		case model.VoteAbstain:
+			abstainVotes.Add(abstainVotes, weight)
+		}
+	}
+
+	totalSupply, err := repository.GetTotalSupplyAt(stub, proposal.Token, proposal.SnapshotID)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	proposal.Tally(forVotes, againstVotes, abstainVotes, totalSupply)
+
+	err = repository.SaveProposal(stub, proposal)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	return shim.Success([]byte(proposal.State))
+}
+
+// executeProposal is invoke fnc that runs the actions of a succeeded proposal once
+// anyone can submit it
+// params - proposal id
+func (cc *Controller) executeProposal(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	proposalID := params[0]
+
+	proposal, err := repository.GetProposal(stub, proposalID)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	if proposal.State != model.ProposalSucceeded {
+		return shim.Error("proposal " + proposalID + " is " + proposal.State)
+	}
+
+	// governance token may have changed since the proposal
+	err = checkProposalActions(stub, proposal.Token, proposal.Actions)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	for _, action := range proposal.Actions {
+		err = executeProposalAction(stub, action)
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+	}
+
+	proposal.State = model.ProposalExecuted
+
+	err = repository.SaveProposal(stub, proposal)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	return shim.Success([]byte("executeProposal success"))
+}
+
+// proposalInfo is query fnc
+// params - proposal id
+// return - proposal
+func (cc *Controller) proposalInfo(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	proposal, err := repository.GetProposal(stub, params[0])
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	proposalBytes, err := json.Marshal(proposal)
+	if err != nil {
+		return shim.Error("failed to Marshal proposal, error : " + err.Error())
+	}
+
+	return shim.Success(proposalBytes)
+}
+
+// setGovernanceConfig validates and saves config
+// params - token name, quorum(basis points), threshold(basis points), proposal threshold(basis points)
+func setGovernanceConfig(stub shim.ChaincodeStubInterface, params []string) error {
+
+	tokenName := params[0]
+
+	quorum, err := strconv.ParseInt(params[1], 10, 64)
+	if err != nil || quorum < 0 || quorum > model.BasisPoints {
+		return model.NewCustomError(model.ConvertErrorType, "quorum", "must be basis points between 0 and 10000")
+	}
+
+	threshold, err := strconv.ParseInt(params[2], 10, 64)
+	if err != nil || threshold < 0 || threshold >= model.BasisPoints {
+		return model.NewCustomError(model.ConvertErrorType, "threshold", "must be basis points between 0 and 9999")
+	}
+
+	proposalThreshold, err := strconv.ParseInt(params[3], 10, 64)
+	if err != nil || proposalThreshold < 0 || proposalThreshold > model.BasisPoints {
+		return model.NewCustomError(model.ConvertErrorType, "proposal threshold", "must be basis points between 0 and 10000")
+	}
+
+	_, err = repository.GetERC20Metadata(stub, tokenName)
+	if err != nil {
+		return err
+	}
+
+	return repository.SaveGovernanceConfig(stub, model.NewGovernanceConfig(tokenName, quorum, threshold, proposalThreshold))
+}
+
+// checkProposalActions returns error if an action is not an admin function a proposal of token can run
+// actions run in one transaction, so an action can not change state another action changes or reads
+func checkProposalActions(stub shim.ChaincodeStubInterface, tokenName string, actions []model.ProposalAction) error {
+
+	if len(actions) == 0 {
+		return model.NewCustomError(model.ConvertErrorType, "actions", "proposal has no action")
+	}
+
+	governanceToken, err := repository.GetGovernanceToken(stub)
+	if err != nil {
+		return err
+	}
+
+	changed, read := map[string]bool{}, map[string]bool{}
+	for _, action := range actions {
+		paramCount, ok := proposalActionParams[action.Function]
+		if !ok {
+			return model.NewCustomError(model.ConvertErrorType, "actions", action.Function+" cannot be run by proposal")
+		}
+
+		if len(action.Args) < paramCount || len(action.Args) > paramCount+proposalActionOptional[action.Function] {
+			return model.NewCustomError(model.ConvertErrorType, "actions", action.Function+" only "+strconv.Itoa(paramCount)+" params")
+		}
+
+		// actions without token are chaincode wide, only proposals of the governance token can run them
+		actionToken := proposalActionToken(action)
+		if actionToken == "" && tokenName != governanceToken {
+			return model.NewCustomError(model.AuthorizeErrorType, "actions", action.Function+" can be run only by proposal of the governance token")
+		}
+
+		if actionToken != "" && actionToken != tokenName {
+			return model.NewCustomError(model.AuthorizeErrorType, "actions", action.Function+" can change only token "+tokenName)
+		}
+
+		// state keys changed and read by action
+		var changes, reads []string
+		switch action.Function {
+		case "mint":
+			_, err := util.ConvertToPositive("mint amount", action.Args[2])
+			if err != nil {
+				return err
+			}
+			changes = []string{"token/" + action.Args[0]}
+			reads = []string{"paused"}
+		case "setGovernanceConfig":
+			changes = []string{"governanceConfig/" + action.Args[0]}
+		case "pause", "unpause":
+			changes = []string{"paused"}
+		case "grantRole", "revokeRole":
+			err := checkRoleScope(stub, actionToken, action.Args[0])
+			if err != nil {
+				return err
+			}
+			// revoking ADMIN reads the members of the role, so one action per role
+			changes = []string{"role/" + actionToken + "/" + action.Args[0]}
+		}
+
+		for _, state := range changes {
+			if changed[state] || read[state] {
+				return model.NewCustomError(model.ConvertErrorType, "actions", "two actions use "+state)
+			}
+		}
+		for _, state := range reads {
+			if changed[state] {
+				return model.NewCustomError(model.ConvertErrorType, "actions", "two actions use "+state)
+			}
+		}
+
+		for _, state := range changes {
+			changed[state] = true
+		}
+		for _, state := range reads {
+			read[state] = true
+		}
+	}
+
+	return nil
+}
+
+// proposalActionToken returns the token action changes, empty for a chaincode wide action
+func proposalActionToken(action model.ProposalAction) string {
+
+	switch action.Function {
+	case "mint", "setGovernanceConfig":
+		return action.Args[0]
+	case "grantRole", "revokeRole":
+		return optionalParam(action.Args, 2)
+	default:
+		return ""
+	}
+}
+
+// executeProposalAction runs action with the governance as the actor
+// actions are checked by checkProposalActions when proposed and again when executed
+func executeProposalAction(stub shim.ChaincodeStubInterface, action model.ProposalAction) error {
+
+	switch action.Function {
+	case "mint":
+		amount, err := util.ConvertToPositive("mint amount", action.Args[2])
+		if err != nil {
+			return err
+		}
+
+		// the guarantees of pause and freeze hold for minting by proposal too
+		err = requireNotPaused(stub)
+		if err != nil {
+			return err
+		}
+
+		err = requireNotFrozen(stub, action.Args[1])
+		if err != nil {
+			return err
+		}
+
+		return mint(stub, action.Args[0], action.Args[1], amount)
+	case "pause":
+		return setPaused(stub, true, governanceAddress)
+	case "unpause":
+		return setPaused(stub, false, governanceAddress)
+	case "grantRole":
+		return repository.GrantRole(stub, proposalActionToken(action), action.Args[0], action.Args[1])
+	case "revokeRole":
+		return revokeRole(stub, proposalActionToken(action), action.Args[0], action.Args[1])
+	case "setGovernanceConfig":
+		return setGovernanceConfig(stub, action.Args)
+	default:
+		return model.NewCustomError(model.ConvertErrorType, "actions", action.Function+" cannot be run by proposal")
+	}
+}
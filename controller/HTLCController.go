@@ -0,0 +1,223 @@
+package controller
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"hyperledger_dapp/identity"
+	"hyperledger_dapp/model"
+	"hyperledger_dapp/repository"
+	"hyperledger_dapp/util"
+	"strconv"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	sc "github.com/hyperledger/fabric-protos-go/peer"
+)
+
+// htlcEscrow is the account holding the tokens of open locks
+var htlcEscrow = identity.EscrowAddress("htlc")
+
+// htlcLock is invoke fnc that moves amount token of sender into escrow
+// recipient can claim it with the preimage of hashlock until timelock, then sender can refund it
+// params - token name, sender's address, recipient's address, amount, hashlock(hex sha256), timelock(unix seconds)
+// return - lock id
+func (cc *Controller) htlcLock(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	tokenName, senderAddress, recipientAddress, lockAmount, hashlock, timelock := params[0], params[1], params[2], params[3], params[4], params[5]
+
+	lockAmountInt, err := util.ConvertToPositive("lock amount", lockAmount)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// hashlock is a sha256 hash
+	hashlockBytes, err := hex.DecodeString(hashlock)
+	if err != nil || len(hashlockBytes) != sha256.Size {
+		return shim.Error("hashlock must be hex of 32 bytes")
+	}
+
+	timelockInt, err := strconv.ParseInt(timelock, 10, 64)
+	if err != nil {
+		return shim.Error("timelock must be unix seconds")
+	}
+
+	// frozen accounts cannot send or receive
+	err = requireNotFrozen(stub, senderAddress, recipientAddress)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// sender must be the submitter
+	err = identity.CheckCaller(stub, senderAddress)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	timestamp, err := stub.GetTxTimestamp()
+	if err != nil {
+		return shim.Error("failed to GetTxTimestamp, error : " + err.Error())
+	}
+
+	if timelockInt <= timestamp.GetSeconds() {
+		return shim.Error("timelock must be in the future")
+	}
+
+	htlc := model.NewHTLC(newRecordID(stub), tokenName, senderAddress, recipientAddress, lockAmountInt, hex.EncodeToString(hashlockBytes), timelockInt)
+
+	err = transfer(stub, tokenName, senderAddress, htlcEscrow, lockAmountInt)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	err = repository.SaveHTLC(stub, htlc)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	err = repository.EmitHTLCEvent(stub, repository.HTLCLockEventKey, htlc)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	return shim.Success([]byte(htlc.ID))
+}
+
+// htlcClaim is invoke fnc that pays the escrowed token to recipient
+// anyone can submit the preimage before timelock
+// params - lock id, preimage(hex)
+func (cc *Controller) htlcClaim(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	id, preimage := params[0], params[1]
+
+	preimageBytes, err := hex.DecodeString(preimage)
+	if err != nil {
+		return shim.Error("preimage must be hex")
+	}
+
+	htlc, err := getOpenHTLC(stub, id)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	timestamp, err := stub.GetTxTimestamp()
+	if err != nil {
+		return shim.Error("failed to GetTxTimestamp, error : " + err.Error())
+	}
+
+	if timestamp.GetSeconds() >= htlc.Timelock {
+		return shim.Error("htlc " + id + " is expired")
+	}
+
+	// preimage must match hashlock
+	hash := sha256.Sum256(preimageBytes)
+	if hex.EncodeToString(hash[:]) != htlc.Hashlock {
+		return forbidden(model.NewCustomError(model.VerifyErrorType, "preimage", "does not match hashlock"))
+	}
+
+	err = requireNotFrozen(stub, htlc.Recipient)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	err = transfer(stub, htlc.Token, htlcEscrow, htlc.Recipient, htlc.GetAmount())
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// preimage is published so the counterparty can claim on the other side
+	htlc.Status = model.HTLCClaimed
+	htlc.Preimage = hex.EncodeToString(preimageBytes)
+
+	err = repository.SaveHTLC(stub, htlc)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	err = repository.EmitHTLCEvent(stub, repository.HTLCClaimEventKey, htlc)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	return shim.Success([]byte("htlcClaim success"))
+}
+
+// htlcRefund is invoke fnc that returns the escrowed token to sender
+// anyone can submit it once timelock has passed
+// params - lock id
+func (cc *Controller) htlcRefund(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	id := params[0]
+
+	htlc, err := getOpenHTLC(stub, id)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	timestamp, err := stub.GetTxTimestamp()
+	if err != nil {
+		return shim.Error("failed to GetTxTimestamp, error : " + err.Error())
+	}
+
+	if timestamp.GetSeconds() < htlc.Timelock {
+		return shim.Error("htlc " + id + " is not expired")
+	}
+
+	err = requireNotFrozen(stub, htlc.Sender)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	err = transfer(stub, htlc.Token, htlcEscrow, htlc.Sender, htlc.GetAmount())
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	htlc.Status = model.HTLCRefunded
+
+	err = repository.SaveHTLC(stub, htlc)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	err = repository.EmitHTLCEvent(stub, repository.HTLCRefundEventKey, htlc)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	return shim.Success([]byte("htlcRefund success"))
+}
+
+// htlcLocks is query fnc
+// params - address
+// return - open locks where address is sender or recipient
+func (cc *Controller) htlcLocks(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	address := params[0]
+
+	htlcs, err := repository.ListOpenHTLCs(stub, address)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	htlcsBytes, err := json.Marshal(htlcs)
+	if err != nil {
+		return shim.Error("failed to Marshal htlcs, error : " + err.Error())
+	}
+
+	return shim.Success(htlcsBytes)
+}
+
+// getOpenHTLC returns the lock if it is neither claimed nor refunded
+func getOpenHTLC(stub shim.ChaincodeStubInterface, id string) (*model.HTLC, error) {
+
+	htlc, err := repository.GetHTLC(stub, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if htlc.Status != model.HTLCOpen {
+		return nil, model.NewCustomError(model.VerifyErrorType, id, "htlc is "+htlc.Status)
+	}
+
+	return htlc, nil
+}
@@ -2,140 +2,124 @@ package controller
 
 // atoi 메서드 util화 시키기
 import (
-	"encoding/json"
 	"fmt"
+	"hyperledger_dapp/identity"
 	"hyperledger_dapp/model"
 	"hyperledger_dapp/repository"
 	"hyperledger_dapp/util"
-	"strconv"
+	"math/big"
+	"sort"
 
 	"github.com/hyperledger/fabric-chaincode-go/shim"
 	sc "github.com/hyperledger/fabric-protos-go/peer"
 )
 
-// Transfer is invoke fnc that moves amount token
+// transfer is invoke fnc that moves amount token
 // from the caller's address to recipient
-// params - caller's address, recipient's address, amount of token
-func (cc *Controller) Transfer(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+// params - token name, caller's address, recipient's address, amount of token
+func (cc *Controller) transfer(stub shim.ChaincodeStubInterface, params []string) sc.Response {
 
-	// check parameter
-	if len(params) != 3 {
-		shim.Error("Transfer only 3 params")
-	}
-
-	callerAddress, recipientAddress, transferAmount := params[0], params[1], params[2]
-	transferAmountInt, err := util.ConvertToPositive("transfer amount", transferAmount)
+	// the submitter is the acting account
+	signerAddress, err := identity.GetCallerAddress(stub)
 	if err != nil {
 		return shim.Error(err.Error())
 	}
 
-	// get caller amount
-	callerAmount, err := repository.GetBalance(stub, callerAddress, false)
+	return cc.transferAs(stub, signerAddress, params)
+}
+
+// transferAs runs Transfer with signerAddress as the acting account
+func (cc *Controller) transferAs(stub shim.ChaincodeStubInterface, signerAddress string, params []string) sc.Response {
+
+	tokenName, callerAddress, recipientAddress, transferAmount := params[0], params[1], params[2], params[3]
+	transferAmountInt, err := util.ConvertToPositive("transfer amount", transferAmount)
 	if err != nil {
 		return shim.Error(err.Error())
 	}
 
-	// get recipient amount
-	recipientAmount, err := repository.GetBalance(stub, callerAddress, false)
+	// frozen accounts cannot send, receive or approve
+	err = requireNotFrozen(stub, callerAddress, recipientAddress)
 	if err != nil {
 		return shim.Error(err.Error())
 	}
 
-	// calculate amount
-	callerResultAmount := *callerAmount - *transferAmountInt
-	recipientResultAmount := *recipientAmount + *transferAmountInt
-
-	// check calculate amount is positive
-	if callerResultAmount < 0 {
-		return shim.Error("caller's balance is not sufficient")
-	}
-
-	// save the caller & recipient amount
-	err = repository.SaveBalance(stub, callerAddress, strconv.Itoa(callerResultAmount))
+	// caller must be the acting account
+	err = identity.CheckAddress(signerAddress, callerAddress)
 	if err != nil {
 		return shim.Error(err.Error())
 	}
 
-	err = repository.SaveBalance(stub, callerAddress, strconv.Itoa(recipientResultAmount))
+	err = transferWithFee(stub, tokenName, callerAddress, recipientAddress, transferAmountInt)
 	if err != nil {
 		return shim.Error(err.Error())
 	}
 
-	// emit transfer event
-	transferEvent := model.NewTransferEvent(callerAddress, recipientAddress, *transferAmountInt)
+	return shim.Success([]byte("transfer Success"))
+}
 
-	transferEventBytes, err := json.Marshal(transferEvent)
-	if err != nil {
-		return shim.Error("failed to marshal transferEvent, error :" + err.Error())
-	}
+// approve is invoke fnc that sets amount as the allowance
+// of spender over the owner tokens
+// params - token name, owner's address, spender's address, amount of token
+func (cc *Controller) approve(stub shim.ChaincodeStubInterface, params []string) sc.Response {
 
-	err = stub.SetEvent("transferEvent", transferEventBytes)
+	// the submitter is the acting account
+	signerAddress, err := identity.GetCallerAddress(stub)
 	if err != nil {
-		return shim.Error("failed to SetEvent of transferEvent, error :" + err.Error())
+		return shim.Error(err.Error())
 	}
 
-	fmt.Println(callerAddress + " send " + transferAmount + " to " + recipientAddress)
-
-	return shim.Success([]byte("transfer Success"))
+	return cc.approveAs(stub, signerAddress, params)
 }
 
-// Approve is invoke fnc that sets amount as the allowance
-// of spender over the owner tokens
-// params - owner's address, spender's address, amount of token
-func (cc *Controller) Approve(stub shim.ChaincodeStubInterface, params []string) sc.Response {
-
-	// check the number of params is 3
-	if len(params) != 3 {
-		return shim.Error("Approve only 3 params")
-	}
+// approveAs runs Approve with signerAddress as the acting account
+func (cc *Controller) approveAs(stub shim.ChaincodeStubInterface, signerAddress string, params []string) sc.Response {
 
-	ownerAddress, spenderAddress, allowanceAmount := params[0], params[1], params[2]
+	tokenName, ownerAddress, spenderAddress, allowanceAmount := params[0], params[1], params[2], params[3]
 
 	// check amount is integer & positive
-	// allowanceAmountInt, err := strconv.Atoi(allowanceAmount)
 	allowanceAmountInt, err := util.ConvertToPositive(" Amount int ", allowanceAmount)
 	if err != nil {
 		return shim.Error(err.Error())
 	}
 
-	// create composite key for allowance - approval/{owner}/{spender}
-	approvalKey, err := stub.CreateCompositeKey("approval", []string{ownerAddress, spenderAddress})
-	if err != nil {
-		return shim.Error("failed to create CompositeKey for approval")
-	}
-
-	// save allowance amount
-	err = stub.PutState(approvalKey, []byte(allowanceAmount))
+	// frozen accounts cannot send, receive or approve
+	err = requireNotFrozen(stub, ownerAddress, spenderAddress)
 	if err != nil {
-		return shim.Error("failed to PutState for approvalKey")
+		return shim.Error(err.Error())
 	}
 
-	// emit approval event
-
-	approvalEvent := model.NewApproval(spenderAddress, ownerAddress, *allowanceAmountInt)
-	approvalBytes, err := json.Marshal(approvalEvent)
+	// owner must be the acting account
+	err = identity.CheckAddress(signerAddress, ownerAddress)
 	if err != nil {
-		return shim.Error("failed to Marshal for approvalEvent")
+		return shim.Error(err.Error())
 	}
 
-	err = stub.SetEvent("approvalEvent", approvalBytes)
+	err = approve(stub, tokenName, ownerAddress, spenderAddress, allowanceAmountInt)
 	if err != nil {
-		return shim.Error("failed to marshal for approval SetEvent")
+		return shim.Error(err.Error())
 	}
 
 	return shim.Success([]byte("approve success"))
 }
 
-// TransferFrom is invoke fnc that moves amount of token from sender to recipient
+// transferFrom is invoke fnc that moves amount of token from sender to recipient
 // using allowance of sender
-// params - owner's address, spender's address, recipient's address, amount of token
-func (cc *Controller) TransferFrom(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+// params - token name, owner's address, spender's address, recipient's address, amount of token
+func (cc *Controller) transferFrom(stub shim.ChaincodeStubInterface, params []string) sc.Response {
 
-	if len(params) != 4 {
-		return shim.Error("transferFrom only 4 params")
+	// the submitter is the acting account
+	signerAddress, err := identity.GetCallerAddress(stub)
+	if err != nil {
+		return shim.Error(err.Error())
 	}
 
-	ownerAddress, spenderAddress, recipientAddress, transferAmount := params[0], params[1], params[2], params[3]
+	return cc.transferFromAs(stub, signerAddress, params)
+}
+
+// transferFromAs runs TransferFrom with signerAddress as the acting account
+func (cc *Controller) transferFromAs(stub shim.ChaincodeStubInterface, signerAddress string, params []string) sc.Response {
+
+	tokenName, ownerAddress, spenderAddress, recipientAddress, transferAmount := params[0], params[1], params[2], params[3], params[4]
 
 	// check amount is integer & positive
 	transferAmountInt, err := util.ConvertToPositive(" Amount ", transferAmount)
@@ -143,49 +127,50 @@ func (cc *Controller) TransferFrom(stub shim.ChaincodeStubInterface, params []st
 		return shim.Error(err.Error())
 	}
 
-	// get allowance
-	allowanceResponse := cc.Allowance(stub, []string{ownerAddress, spenderAddress})
+	// frozen accounts cannot send, receive or approve
+	err = requireNotFrozen(stub, ownerAddress, spenderAddress, recipientAddress)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
 
-	if allowanceResponse.GetStatus() >= 400 {
-		return shim.Error("failed to get allowance, error : " + allowanceResponse.GetMessage())
+	// spender must be the acting account
+	err = identity.CheckAddress(signerAddress, spenderAddress)
+	if err != nil {
+		return shim.Error(err.Error())
 	}
 
-	// convert allowance response payload to allowance data
+	// get allowance
+	allowanceInt, err := repository.GetAllowance(stub, tokenName, ownerAddress, spenderAddress)
+	if err != nil {
+		return shim.Error("failed to get allowance, error : " + err.Error())
+	}
 
-	allowanceInt, err := util.ConvertToPositive("Payload", string(allowanceResponse.GetPayload()))
+	// decrease allowance amount
+	approveAmountInt, err := util.SubAmount("allowance", allowanceInt, transferAmountInt)
 	if err != nil {
 		return shim.Error(err.Error())
 	}
 
 	// transfer from owner to recipient
-	transferResponse := cc.Transfer(stub, []string{ownerAddress, recipientAddress, transferAmount})
-	if transferResponse.GetStatus() >= 400 {
-		return shim.Error("failed to get transfer error : " + err.Error())
+	err = transferWithFee(stub, tokenName, ownerAddress, recipientAddress, transferAmountInt)
+	if err != nil {
+		return shim.Error("failed to transfer, error : " + err.Error())
 	}
 
-	// decrease allowance amount
-	approveAmountInt := *allowanceInt - *transferAmountInt
-	approveAmount := strconv.Itoa(approveAmountInt)
-
 	// approve amount of tokens trasfered
-	approveResponse := cc.Approve(stub, []string{ownerAddress, spenderAddress, approveAmount})
-	if approveResponse.GetStatus() >= 400 {
-		return shim.Error("failed to get transfer error : " + err.Error())
+	err = approve(stub, tokenName, ownerAddress, spenderAddress, approveAmountInt)
+	if err != nil {
+		return shim.Error("failed to approve, error : " + err.Error())
 	}
 
 	return shim.Success([]byte("transferFrom success"))
 }
 
-// IncreaseAllowance is invoke fnc that increases spender's allowance by owner
-// params - owner's address, spender's addresss, amount of token
-func (cc *Controller) IncreaseAllowance(stub shim.ChaincodeStubInterface, params []string) sc.Response {
-
-	// check params 3
-	if len(params) != 3 {
-		return shim.Error("increaseAllowance only 3 params")
-	}
+// increaseAllowance is invoke fnc that increases spender's allowance by owner
+// params - token name, owner's address, spender's addresss, amount of token
+func (cc *Controller) increaseAllowance(stub shim.ChaincodeStubInterface, params []string) sc.Response {
 
-	ownerAddress, spenderAddress, increaseAmount := params[0], params[1], params[2]
+	tokenName, ownerAddress, spenderAddress, increaseAmount := params[0], params[1], params[2], params[3]
 
 	// check amount is integer & positive
 	increaseAmountInt, err := util.ConvertToPositive("Amount", increaseAmount)
@@ -193,87 +178,97 @@ func (cc *Controller) IncreaseAllowance(stub shim.ChaincodeStubInterface, params
 		return shim.Error(err.Error())
 	}
 
-	// get allowance
-	allowanceResponse := cc.Allowance(stub, []string{ownerAddress, spenderAddress})
-	if allowanceResponse.GetStatus() >= 400 {
-		return shim.Error("failed to get allowance, error : " + allowanceResponse.GetMessage())
+	// frozen accounts cannot send, receive or approve
+	err = requireNotFrozen(stub, ownerAddress, spenderAddress)
+	if err != nil {
+		return shim.Error(err.Error())
 	}
 
-	// convert allowance response payload to allowance data
-	allowanceInt, err := util.ConvertToPositive("allowance", string(allowanceResponse.GetPayload()))
+	// owner must be the submitter
+	err = identity.CheckCaller(stub, ownerAddress)
 	if err != nil {
 		return shim.Error(err.Error())
 	}
 
+	// get allowance
+	allowanceInt, err := repository.GetAllowance(stub, tokenName, ownerAddress, spenderAddress)
+	if err != nil {
+		return shim.Error("failed to get allowance, error : " + err.Error())
+	}
+
 	// increase allowance
-	resultAmountInt := *allowanceInt + *increaseAmountInt
-	resultAmount := strconv.Itoa(resultAmountInt)
+	resultAmountInt, err := util.AddAmount("allowance", allowanceInt, increaseAmountInt)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
 
-	// call approve
-	approveResponse := cc.Approve(stub, []string{ownerAddress, spenderAddress, resultAmount})
-	if approveResponse.GetStatus() >= 400 {
-		return shim.Error("failed to get allowance, error : " + approveResponse.GetMessage())
+	err = approve(stub, tokenName, ownerAddress, spenderAddress, resultAmountInt)
+	if err != nil {
+		return shim.Error("failed to approve, error : " + err.Error())
 	}
 
 	return shim.Success([]byte("increaseAllowance success"))
 }
 
-// DecreaseAllowance is invoke fnc that decreases spender's allowance by owner
-// params - owner's address, spender's addresss, amount of token
-func (cc *Controller) DecreaseAllowance(stub shim.ChaincodeStubInterface, params []string) sc.Response {
-
-	// check params 3
-	if len(params) != 3 {
-		return shim.Error("DecreaseAllowance only 3 params")
-	}
+// decreaseAllowance is invoke fnc that decreases spender's allowance by owner
+// params - token name, owner's address, spender's addresss, amount of token
+func (cc *Controller) decreaseAllowance(stub shim.ChaincodeStubInterface, params []string) sc.Response {
 
-	ownerAddress, spenderAddress, decreaseAmount := params[0], params[1], params[2]
+	tokenName, ownerAddress, spenderAddress, decreaseAmount := params[0], params[1], params[2], params[3]
 
 	// check amount is integer & positive
-
 	decreaseAmountInt, err := util.ConvertToPositive("Amount", decreaseAmount)
 	if err != nil {
 		return shim.Error(err.Error())
 	}
 
-	// get allowance
-	allowanceResponse := cc.Allowance(stub, []string{ownerAddress, spenderAddress})
-	if allowanceResponse.GetStatus() >= 400 {
-		return shim.Error("failed to get allowance, error : " + allowanceResponse.GetMessage())
+	// frozen accounts cannot send, receive or approve
+	err = requireNotFrozen(stub, ownerAddress, spenderAddress)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// owner must be the submitter
+	err = identity.CheckCaller(stub, ownerAddress)
+	if err != nil {
+		return shim.Error(err.Error())
 	}
 
-	// convert allowance response payload to allowance data
-	allowanceInt, err := util.ConvertToPositive("allowance", decreaseAmount)
+	// get allowance
+	allowanceInt, err := repository.GetAllowance(stub, tokenName, ownerAddress, spenderAddress)
 	if err != nil {
-		return shim.Error("allowance must be positive")
+		return shim.Error("failed to get allowance, error : " + err.Error())
 	}
 
 	// decrease allowance
-	resultAmountInt := *allowanceInt + *decreaseAmountInt
-	resultAmount := strconv.Itoa(resultAmountInt)
+	resultAmountInt, err := util.SubAmount("allowance", allowanceInt, decreaseAmountInt)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
 
-	// call approve
-	approveResponse := cc.Approve(stub, []string{ownerAddress, spenderAddress, resultAmount})
-	if approveResponse.GetStatus() >= 400 {
-		return shim.Error("failed to get allowance, error : " + approveResponse.GetMessage())
+	err = approve(stub, tokenName, ownerAddress, spenderAddress, resultAmountInt)
+	if err != nil {
+		return shim.Error("failed to approve, error : " + err.Error())
 	}
 
 	return shim.Success([]byte("decreaseAllowance success"))
 }
 
-// TransferOtherToken is invoke fnc that moves amount other chaincode tokens
+// transferOtherToken is invoke fnc that moves amount other chaincode tokens
 // from the caller's addresss to recipient
-// params - chaincode name, caller's addresss, recipient's address, amount
-func (cc *Controller) TransferOtherToken(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+// params - chaincode name, token name, caller's addresss, recipient's address, amount
+func (cc *Controller) transferOtherToken(stub shim.ChaincodeStubInterface, params []string) sc.Response {
 
-	if len(params) != 4 {
-		return shim.Error("transferFrom only 4 params")
-	}
+	chaincodeName, tokenName, callerAddress, recipientAddress, transferAmount := params[0], params[1], params[2], params[3], params[4]
 
-	chaincodeName, callerAddress, recipientAddress, transferAmount := params[0], params[1], params[2], params[3]
+	// caller must be the submitter
+	err := identity.CheckCaller(stub, callerAddress)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
 
 	// make arguments
-	args := [][]byte{[]byte("transfer"), []byte(callerAddress), []byte(recipientAddress), []byte(transferAmount)}
+	args := [][]byte{[]byte("transfer"), []byte(tokenName), []byte(callerAddress), []byte(recipientAddress), []byte(transferAmount)}
 
 	// get channel
 	channel := stub.GetChannelID()
@@ -291,14 +286,15 @@ func (cc *Controller) TransferOtherToken(stub shim.ChaincodeStubInterface, param
 
 // mint is invoke fnc that creates amount tokens and assign them to address, increasing the total supply
 // param - token name, recipient address, amount token
-func (cc *Controller) Mint(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+func (cc *Controller) mint(stub shim.ChaincodeStubInterface, params []string) sc.Response {
 
-	// chk parameter
-	if len(params) != 3 {
-		return shim.Error("Mint only 3 params")
-	}
 	tokenName, owner, mintAmount := params[0], params[1], params[2]
 
+	minterAddress, err := identity.GetCallerAddress(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
 	// amount must be positive
 	mintAmountInt, err := util.ConvertToPositive("mint amount", mintAmount)
 
@@ -306,40 +302,282 @@ func (cc *Controller) Mint(stub shim.ChaincodeStubInterface, params []string) sc
 		return shim.Error(err.Error())
 	}
 
-	// increase total supply
-	erc20Metadata, err := repository.GetERC20Metadata(stub, tokenName)
+	// frozen accounts cannot mint or receive minted tokens
+	err = requireNotFrozen(stub, minterAddress, owner)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	err = mint(stub, tokenName, owner, mintAmountInt)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	return shim.Success([]byte("mint success"))
+}
+
+// burn is invoke fnc that destroys amount tokens of the caller, decreasing the total supply
+// any holder burns own tokens, BURNER role is only required to burn tokens of others with burnFrom
+// params - token name, caller's address, amount token
+func (cc *Controller) burn(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	tokenName, holderAddress, burnAmount := params[0], params[1], params[2]
+
+	// amount must be positive
+	burnAmountInt, err := util.ConvertToPositive("burn amount", burnAmount)
 	if err != nil {
 		return shim.Error(err.Error())
 	}
 
-	resultTotalSupply := *erc20Metadata.GetTotalSupply() + uint64(*mintAmountInt)
+	// holder must be the submitter
+	err = identity.CheckCaller(stub, holderAddress)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
 
-	err = repository.SaveERC20Metadata(stub, erc20Metadata.Name, erc20Metadata.Symbol, erc20Metadata.Owner, uint(resultTotalSupply))
+	// frozen accounts cannot burn like they cannot mint
+	err = requireNotFrozen(stub, holderAddress)
 	if err != nil {
 		return shim.Error(err.Error())
 	}
 
-	// increase owner balance
-	curBalance, err := repository.GetBalance(stub, owner, false)
+	err = burn(stub, tokenName, holderAddress, burnAmountInt)
 	if err != nil {
 		return shim.Error(err.Error())
 	}
 
-	resultBalance := *curBalance + *mintAmountInt
+	return shim.Success([]byte("burn success"))
+}
+
+// burnFrom is invoke fnc that destroys amount tokens of owner using allowance of spender
+// params - token name, owner's address, spender's address, amount token
+func (cc *Controller) burnFrom(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	tokenName, ownerAddress, spenderAddress, burnAmount := params[0], params[1], params[2], params[3]
 
-	err = repository.SaveBalance(stub, owner, strconv.Itoa(resultBalance))
+	// amount must be positive
+	burnAmountInt, err := util.ConvertToPositive("burn amount", burnAmount)
 	if err != nil {
 		return shim.Error(err.Error())
 	}
 
-	// emit transfer event
-	err = repository.EmitTransferEvent(stub, "admin", tokenName, *mintAmountInt)
+	// spender must be the submitter
+	err = identity.CheckCaller(stub, spenderAddress)
 	if err != nil {
 		return shim.Error(err.Error())
 	}
-	return shim.Success([]byte("mint success"))
+
+	// frozen accounts cannot burn like they cannot mint
+	err = requireNotFrozen(stub, ownerAddress, spenderAddress)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// get allowance
+	allowanceInt, err := repository.GetAllowance(stub, tokenName, ownerAddress, spenderAddress)
+	if err != nil {
+		return shim.Error("failed to get allowance, error : " + err.Error())
+	}
+
+	// decrease allowance amount
+	approveAmountInt, err := util.SubAmount("allowance", allowanceInt, burnAmountInt)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	err = burn(stub, tokenName, ownerAddress, burnAmountInt)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	err = approve(stub, tokenName, ownerAddress, spenderAddress, approveAmountInt)
+	if err != nil {
+		return shim.Error("failed to approve, error : " + err.Error())
+	}
+
+	return shim.Success([]byte("burnFrom success"))
+}
+
+// transfer moves amount token from sender to recipient and emits transfer event
+// transfer and the other token helpers leave the authorization to the calling fnc
+func transfer(stub shim.ChaincodeStubInterface, tokenName, senderAddress, recipientAddress string, amount *big.Int) error {
+
+	// changes of self transfer cancel out in balanceChanges, so only the balance is checked
+	if senderAddress == recipientAddress {
+		senderAmount, err := repository.GetBalance(stub, tokenName, senderAddress, true)
+		if err != nil {
+			return err
+		}
+
+		_, err = util.SubAmount("balance", senderAmount, amount)
+		if err != nil {
+			return err
+		}
+	}
+
+	changes := balanceChanges{}
+	changes.add(senderAddress, new(big.Int).Neg(amount))
+	changes.add(recipientAddress, amount)
+
+	err := changes.apply(stub, tokenName)
+	if err != nil {
+		return err
+	}
+
+	// emit transfer event
+	return repository.EmitTransferEvent(stub, tokenName, senderAddress, recipientAddress, amount)
+}
+
+// balanceChanges is the balance delta of each address in a transaction
+// fabric does not read its own writes, so legs touching the same address are summed
+// and every balance is read and saved once
+type balanceChanges map[string]*big.Int
+
+func (changes balanceChanges) add(address string, delta *big.Int) {
+	if changes[address] == nil {
+		changes[address] = new(big.Int)
+	}
+	changes[address].Add(changes[address], delta)
+}
+
+// addresses returns addresses in order, so every endorser applies changes the same way
+func (changes balanceChanges) addresses() []string {
+	addresses := make([]string, 0, len(changes))
+	for address := range changes {
+		addresses = append(addresses, address)
+	}
+	sort.Strings(addresses)
+	return addresses
+}
+
+// apply saves the balances and moves the voting power, balance cannot be negative
+func (changes balanceChanges) apply(stub shim.ChaincodeStubInterface, tokenName string) error {
+
+	for _, address := range changes.addresses() {
+		delta := changes[address]
+		if delta.Sign() == 0 {
+			continue
+		}
+
+		// credit of delta mode address is saved without reading its balance
+		if delta.Sign() > 0 {
+			deltaMode, err := repository.IsDeltaMode(stub, tokenName, address)
+			if err != nil {
+				return err
+			}
+
+			if deltaMode {
+				err = repository.SaveBalanceDelta(stub, tokenName, address, delta)
+				if err != nil {
+					return err
+				}
+				continue
+			}
+		}
+
+		curBalance, err := repository.GetBalance(stub, tokenName, address, true)
+		if err != nil {
+			return err
+		}
+
+		var resultBalance *big.Int
+		if delta.Sign() > 0 {
+			resultBalance, err = util.AddAmount("balance", curBalance, delta)
+		} else {
+			resultBalance, err = util.SubAmount("balance", curBalance, new(big.Int).Neg(delta))
+		}
+		if err != nil {
+			return err
+		}
+
+		err = repository.SaveBalance(stub, tokenName, address, resultBalance)
+		if err != nil {
+			return err
+		}
+	}
+
+	return moveVotingPower(stub, tokenName, changes)
+}
+
+// approve sets amount as the allowance of spender over the owner tokens and emits approval event
+func approve(stub shim.ChaincodeStubInterface, tokenName, ownerAddress, spenderAddress string, amount *big.Int) error {
+
+	// save allowance amount
+	err := repository.SaveAllowance(stub, tokenName, ownerAddress, spenderAddress, amount)
+	if err != nil {
+		return err
+	}
+
+	// emit approval event
+	return repository.EmitApprovalEvent(stub, tokenName, ownerAddress, spenderAddress, amount)
+}
+
+// mint creates amount token for recipient, increases total supply and emits transfer event from zero address
+func mint(stub shim.ChaincodeStubInterface, tokenName, recipientAddress string, amount *big.Int) error {
+
+	// increase total supply
+	erc20Metadata, err := repository.GetERC20Metadata(stub, tokenName)
+	if err != nil {
+		return err
+	}
+
+	resultTotalSupply, err := util.AddAmount("totalSupply", erc20Metadata.GetTotalSupply(), amount)
+	if err != nil {
+		return err
+	}
+
+	capAmount, err := erc20Metadata.GetCap()
+	if err != nil {
+		return err
+	}
+
+	// total supply cannot exceed cap
+	if capAmount != nil && resultTotalSupply.Cmp(capAmount) > 0 {
+		return model.NewCustomError(model.OverflowErrorType, "totalSupply", "mint amount exceeds cap "+capAmount.String())
+	}
+	erc20Metadata.SetTotalSupply(resultTotalSupply)
+
+	err = repository.SaveERC20Metadata(stub, erc20Metadata)
+	if err != nil {
+		return err
+	}
+
+	// increase recipient balance
+	err = balanceChanges{recipientAddress: amount}.apply(stub, tokenName)
+	if err != nil {
+		return err
+	}
+
+	// emit transfer event
+	return repository.EmitTransferEvent(stub, tokenName, identity.ZeroAddress, recipientAddress, amount)
 }
 
-func (cc *Controller) Burn(stub shim.ChaincodeStubInterface, params []string) sc.Response {
-	return shim.Success(nil)
+// burn destroys amount token of holder, decreases total supply and emits transfer event to zero address
+func burn(stub shim.ChaincodeStubInterface, tokenName, holderAddress string, amount *big.Int) error {
+
+	// decrease total supply
+	erc20Metadata, err := repository.GetERC20Metadata(stub, tokenName)
+	if err != nil {
+		return err
+	}
+
+	resultTotalSupply, err := util.SubAmount("totalSupply", erc20Metadata.GetTotalSupply(), amount)
+	if err != nil {
+		return err
+	}
+
+	// decrease holder balance
+	err = balanceChanges{holderAddress: new(big.Int).Neg(amount)}.apply(stub, tokenName)
+	if err != nil {
+		return err
+	}
+
+	erc20Metadata.SetTotalSupply(resultTotalSupply)
+	err = repository.SaveERC20Metadata(stub, erc20Metadata)
+	if err != nil {
+		return err
+	}
+
+	// emit transfer event
+	return repository.EmitTransferEvent(stub, tokenName, holderAddress, identity.ZeroAddress, amount)
 }
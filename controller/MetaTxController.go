@@ -0,0 +1,152 @@
+package controller
+
+import (
+	"encoding/json"
+	"hyperledger_dapp/identity"
+	"hyperledger_dapp/model"
+	"hyperledger_dapp/repository"
+	"hyperledger_dapp/signature"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	sc "github.com/hyperledger/fabric-protos-go/peer"
+)
+
+// signedFunctions are the fncs a signed request can run
+var signedFunctions = map[string]*Function{
+	"transfer":     {Name: "transfer", Params: transferParams},
+	"approve":      {Name: "approve", Params: approveParams},
+	"transferFrom": {Name: "transferFrom", Params: transferFromParams},
+}
+
+// bindSigningKey is invoke fnc that binds a public key to address
+// the key must sign the binding, and anyone can submit it when address is derived from the key
+// otherwise the submitter must be address
+// params - address, curve(P-256 or secp256k1), public key(hex), signature(hex)
+func (cc *Controller) bindSigningKey(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	address, curve, publicKeyHex, signatureHex := params[0], params[1], params[2], params[3]
+
+	publicKey, err := signature.ParsePublicKey(curve, publicKeyHex)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// only the owner of address can bind a key which is not derived from address
+	if address != identity.AddressOfKey(curve, publicKey.String()) {
+		err = identity.CheckCaller(stub, address)
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+	}
+
+	chaincodeName, err := identity.GetChaincodeName(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// the key holder proves possession of the key
+	digest := signature.BindDigest(stub.GetChannelID(), chaincodeName, address, curve, publicKeyHex)
+	err = signature.Verify(publicKey, digest, signatureHex)
+	if err != nil {
+		return forbidden(err)
+	}
+
+	err = repository.SaveSigningKey(stub, model.NewSigningKey(address, curve, publicKey.String()))
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	return shim.Success([]byte(address))
+}
+
+// executeSigned is invoke fnc that runs a request signed by a key holder
+// a relayer submits the request, the request runs as the address bound to the key
+// params - serialized request(JSON of model.SignedRequest), curve, public key(hex), signature(hex)
+func (cc *Controller) executeSigned(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	request, curve, publicKeyHex, signatureHex := params[0], params[1], params[2], params[3]
+
+	publicKey, err := signature.ParsePublicKey(curve, publicKeyHex)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	chaincodeName, err := identity.GetChaincodeName(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	signedRequest := model.SignedRequest{}
+	err = json.Unmarshal([]byte(request), &signedRequest)
+	if err != nil {
+		return shim.Error("failed to Unmarshal request, error : " + err.Error())
+	}
+
+	// signature covers the nonce and the request bytes as submitted
+	digest := signature.RequestDigest(stub.GetChannelID(), chaincodeName, signedRequest.Nonce, request)
+	err = signature.Verify(publicKey, digest, signatureHex)
+	if err != nil {
+		return forbidden(err)
+	}
+
+	signerAddress, err := repository.GetSigningKeyAddress(stub, curve, publicKey.String())
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	if signedRequest.Signer != signerAddress {
+		return shim.Error("request signer does not match the address bound to the key")
+	}
+
+	err = requireDeadline(stub, signedRequest.Expiry)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// nonce is used once
+	err = useNonce(stub, signerAddress, signedRequest.Nonce)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// the request does not go through the router, so its params are checked here
+	function, ok := signedFunctions[signedRequest.Function]
+	if !ok {
+		return shim.Error("executeSigned does not support " + signedRequest.Function)
+	}
+
+	if !function.accepts(len(signedRequest.Args)) {
+		return shim.Error(function.Name + " takes params " + function.usage())
+	}
+
+	switch signedRequest.Function {
+	case "transfer":
+		return cc.transferAs(stub, signerAddress, signedRequest.Args)
+	case "transferFrom":
+		return cc.transferFromAs(stub, signerAddress, signedRequest.Args)
+	case "approve":
+		return cc.approveAs(stub, signerAddress, signedRequest.Args)
+	default:
+		return shim.Error("executeSigned does not support " + signedRequest.Function)
+	}
+}
+
+// signingKeyOf is query fnc
+// params - address
+// return - the signing key bound to address
+func (cc *Controller) signingKeyOf(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	address := params[0]
+
+	signingKey, err := repository.GetSigningKey(stub, address)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	signingKeyBytes, err := json.Marshal(signingKey)
+	if err != nil {
+		return shim.Error("failed to Marshal signingKey, error : " + err.Error())
+	}
+
+	return shim.Success(signingKeyBytes)
+}
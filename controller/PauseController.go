@@ -0,0 +1,104 @@
+package controller
+
+import (
+	"encoding/json"
+	"hyperledger_dapp/identity"
+	"hyperledger_dapp/model"
+	"hyperledger_dapp/repository"
+	"strconv"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	sc "github.com/hyperledger/fabric-protos-go/peer"
+)
+
+// pause is invoke fnc that stops every state-changing token operation
+// only PAUSER or ADMIN can pause
+func (cc *Controller) pause(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	callerAddress, err := identity.GetCallerAddress(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	err = setPaused(stub, true, callerAddress)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	return shim.Success([]byte("pause success"))
+}
+
+// unpause is invoke fnc that resumes token operations
+// only PAUSER or ADMIN can unpause
+func (cc *Controller) unpause(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	callerAddress, err := identity.GetCallerAddress(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	err = setPaused(stub, false, callerAddress)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	return shim.Success([]byte("unpause success"))
+}
+
+// paused is query fnc
+// return - true if token operations are paused
+func (cc *Controller) paused(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	paused, err := repository.GetPaused(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	pausedBytes, err := json.Marshal(paused)
+	if err != nil {
+		return shim.Error("failed to Marshal paused, error : " + err.Error())
+	}
+
+	return shim.Success(pausedBytes)
+}
+
+// setPaused saves pause flag and emits pause or unpause event
+func setPaused(stub shim.ChaincodeStubInterface, paused bool, account string) error {
+
+	// pause twice or unpause twice is an error
+	curPaused, err := repository.GetPaused(stub)
+	if err != nil {
+		return err
+	}
+
+	if curPaused == paused {
+		return model.NewCustomError(model.PausedErrorType, "pause", "paused is already "+strconv.FormatBool(paused))
+	}
+
+	err = repository.SavePaused(stub, paused)
+	if err != nil {
+		return err
+	}
+
+	eventKey := repository.UnpauseEventKey
+	if paused {
+		eventKey = repository.PauseEventKey
+	}
+
+	return repository.EmitPauseEvent(stub, eventKey, account)
+}
+
+// requireNotPaused returns error if token operations are paused
+func requireNotPaused(stub shim.ChaincodeStubInterface) error {
+
+	paused, err := repository.GetPaused(stub)
+	if err != nil {
+		return err
+	}
+
+	if paused {
+		return model.NewCustomError(model.PausedErrorType, "transaction", "token operations are paused")
+	}
+
+	return nil
+}
@@ -0,0 +1,154 @@
+package controller
+
+import (
+	"hyperledger_dapp/identity"
+	"hyperledger_dapp/model"
+	"hyperledger_dapp/repository"
+	"hyperledger_dapp/signature"
+	"hyperledger_dapp/util"
+	"math/big"
+	"strconv"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	sc "github.com/hyperledger/fabric-protos-go/peer"
+)
+
+// registerSigningKey is invoke fnc that binds the public key of the caller's certificate to the caller's address
+// the key is used to verify permit signatures of the caller
+// params - caller's address
+func (cc *Controller) registerSigningKey(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	callerAddress := params[0]
+
+	// caller must be the submitter
+	err := identity.CheckCaller(stub, callerAddress)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	publicKey, err := identity.GetCallerPublicKey(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	if publicKey.Curve.Params().Name != signature.P256 {
+		return shim.Error("caller's key must be on " + signature.P256)
+	}
+
+	signingKey := model.NewSigningKey(callerAddress, signature.P256, signature.MarshalPublicKey(publicKey))
+	err = repository.SaveSigningKey(stub, signingKey)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	return shim.Success([]byte("registerSigningKey success"))
+}
+
+// permit is invoke fnc that sets allowance from a signature of the owner
+// anyone can submit the permit, so the owner does not have to transact
+// params - token name, owner's address, spender's address, amount of token, nonce, deadline(unix seconds), signature(hex of DER)
+func (cc *Controller) permit(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	tokenName, ownerAddress, spenderAddress, value, nonce, deadline, signatureHex := params[0], params[1], params[2], params[3], params[4], params[5], params[6]
+
+	valueInt, err := util.ConvertToAmount("value", value)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// frozen accounts cannot approve
+	err = requireNotFrozen(stub, ownerAddress, spenderAddress)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	err = requireDeadline(stub, deadline)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// verify owner's signature
+	signingKey, err := repository.GetSigningKey(stub, ownerAddress)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	publicKey, err := signature.ParsePublicKey(signingKey.Curve, signingKey.PublicKey)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	chaincodeName, err := identity.GetChaincodeName(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	digest := signature.PermitDigest(stub.GetChannelID(), chaincodeName, tokenName, ownerAddress, spenderAddress, value, nonce, deadline)
+	err = signature.Verify(publicKey, digest, signatureHex)
+	if err != nil {
+		return forbidden(err)
+	}
+
+	// nonce is used once
+	err = useNonce(stub, ownerAddress, nonce)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	err = approve(stub, tokenName, ownerAddress, spenderAddress, valueInt)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	return shim.Success([]byte("permit success"))
+}
+
+// nonces is query fnc
+// params - address
+// return - the nonce the next signature of address must use
+func (cc *Controller) nonces(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	address := params[0]
+
+	nonce, err := repository.GetNonce(stub, address)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	return shim.Success([]byte(nonce.String()))
+}
+
+// requireDeadline returns error if the transaction timestamp is after deadline
+func requireDeadline(stub shim.ChaincodeStubInterface, deadline string) error {
+
+	deadlineInt, err := strconv.ParseInt(deadline, 10, 64)
+	if err != nil {
+		return model.NewCustomError(model.ConvertErrorType, "deadline", " must be unix seconds")
+	}
+
+	timestamp, err := stub.GetTxTimestamp()
+	if err != nil {
+		return model.NewCustomError(model.GetStateErrorType, "timestamp", err.Error())
+	}
+
+	if timestamp.GetSeconds() > deadlineInt {
+		return model.NewCustomError(model.VerifyErrorType, "deadline", "signature is expired")
+	}
+
+	return nil
+}
+
+// useNonce checks nonce is the next nonce of address and increases it
+func useNonce(stub shim.ChaincodeStubInterface, address, nonce string) error {
+
+	curNonce, err := repository.GetNonce(stub, address)
+	if err != nil {
+		return err
+	}
+
+	if curNonce.String() != nonce {
+		return model.NewCustomError(model.VerifyErrorType, "nonce", "expected nonce "+curNonce.String())
+	}
+
+	return repository.SaveNonce(stub, address, new(big.Int).Add(curNonce, big.NewInt(1)))
+}
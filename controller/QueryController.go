@@ -5,21 +5,16 @@ import (
 	"fmt"
 	"hyperledger_dapp/model"
 	"hyperledger_dapp/repository"
-	"strconv"
+	"hyperledger_dapp/util"
 
 	"github.com/hyperledger/fabric-chaincode-go/shim"
 	sc "github.com/hyperledger/fabric-protos-go/peer"
 )
 
-// TotalSupply is query function
+// totalSupply is query function
 // params is tokenName
 // Returns the amount of token in existence
-func (cc *Controller) TotalSupply(stub shim.ChaincodeStubInterface, params []string) sc.Response {
-
-	// a number of params must be one
-	if len(params) != 1 {
-		return shim.Error("TotalSupply only 1 params")
-	}
+func (cc *Controller) totalSupply(stub shim.ChaincodeStubInterface, params []string) sc.Response {
 
 	tokenName := params[0]
 
@@ -29,86 +24,55 @@ func (cc *Controller) TotalSupply(stub shim.ChaincodeStubInterface, params []str
 		return shim.Error(err.Error())
 	}
 
-	// convert metadata to bytes
-	totalsupplyBytes, err := json.Marshal(totalSupply)
-	if err != nil {
-		errMsg := fmt.Sprintf("failed to marshal from TotalSupply, error : %s", err.Error())
-		return shim.Error(errMsg)
-	}
-
-	fmt.Println(tokenName + "'s totalsupply is " + string(totalsupplyBytes))
+	// convert totalsupply to decimal string bytes
+	totalsupplyBytes := []byte(totalSupply.String())
 
 	return shim.Success(totalsupplyBytes)
 }
 
-// BalanceOf is query function
-// params is address
+// balanceOf is query function
+// params is token name, address
 // Returns the amount of tokens owned by address
-func (cc *Controller) BalanceOf(stub shim.ChaincodeStubInterface, params []string) sc.Response {
-	// a number of params must be one
-	if len(params) != 1 {
-		return shim.Error("BalanceOf only 1 params")
-	}
-
-	address := params[0]
+func (cc *Controller) balanceOf(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+	tokenName, address := params[0], params[1]
 
 	// get balance
-	amountBytes, err := stub.GetState(address)
+	amount, err := repository.GetBalance(stub, tokenName, address, true)
 	if err != nil {
-		errMsg := fmt.Sprintf("failed to getstate from balanceOf, error : %s", err.Error())
+		errMsg := fmt.Sprintf("failed to get balance from balanceOf, error : %s", err.Error())
 		return shim.Error(errMsg)
 	}
 
-	fmt.Println(address + "'s totalsupply is " + string(amountBytes))
+	amountBytes := []byte(amount.String())
 
 	return shim.Success(amountBytes)
 }
 
-// Allowance is query fnc
-// params - owner's address, spender's address
+// allowance is query fnc
+// params - token name, owner's address, spender's address
 // return - the remaining amount of token to invoke (transferFrom)
-func (cc *Controller) Allowance(stub shim.ChaincodeStubInterface, params []string) sc.Response {
-
-	// check the number of params is 2
-	if len(params) != 2 {
-		return shim.Error("Allowance only 2 params")
-	}
+func (cc *Controller) allowance(stub shim.ChaincodeStubInterface, params []string) sc.Response {
 
-	ownerAddress, spenderAddress := params[0], params[1]
-
-	// create composite key
-	approvalKey, err := stub.CreateCompositeKey("approval", []string{ownerAddress, spenderAddress})
-	if err != nil {
-		return shim.Error("failed CreateCompositeKey for approval")
-	}
+	tokenName, ownerAddress, spenderAddress := params[0], params[1], params[2]
 
-	// getstate(amount)
-	approvalKeyBytes, err := stub.GetState(approvalKey)
+	// get allowance amount
+	allowance, err := repository.GetAllowance(stub, tokenName, ownerAddress, spenderAddress)
 	if err != nil {
-		return shim.Error("Failed to GetState")
-	}
-
-	if approvalKeyBytes == nil {
-		approvalKeyBytes = []byte("0")
+		return shim.Error(err.Error())
 	}
 
-	return shim.Success(approvalKeyBytes)
+	return shim.Success([]byte(allowance.String()))
 }
 
-// ApprovalList is query fnc
-// params - owner's addresss
+// approvalList is query fnc
+// params - token name, owner's addresss
 // return - approvalList by owner
-func (cc *Controller) ApprovalList(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+func (cc *Controller) approvalList(stub shim.ChaincodeStubInterface, params []string) sc.Response {
 
-	// check the number of params is 1
-	if len(params) != 1 {
-		return shim.Error("allowance only 2 params")
-	}
-
-	ownerAddress := params[0]
+	tokenName, ownerAddress := params[0], params[1]
 
 	// get all approval (format is iterator)
-	approvalIterator, err := stub.GetStateByPartialCompositeKey("approval", []string{ownerAddress})
+	approvalIterator, err := stub.GetStateByPartialCompositeKey(repository.ApprovalPrefix, []string{tokenName, ownerAddress})
 	if err != nil {
 		return shim.Error("failed to GetStateByPartialCompositeKey for approval iterator error :" + err.Error())
 	}
@@ -130,17 +94,17 @@ func (cc *Controller) ApprovalList(stub shim.ChaincodeStubInterface, params []st
 				return shim.Error("failed to SplitCompositeKey, error :" + err.Error())
 			}
 
-			spenderAddress := address[1]
+			spenderAddress := address[2]
 
 			// get amount
-			amount, err := strconv.Atoi(string(approvalValue))
+			amount, err := util.ConvertToAmount("allowance", string(approvalValue))
 			if err != nil {
 				return shim.Error("failed to get amount, error : " + err.Error())
 			}
 
 			// add approval result
 			approvalSlice = append(approvalSlice,
-				*model.NewApproval(spenderAddress, ownerAddress, amount))
+				*model.NewApproval(tokenName, spenderAddress, ownerAddress, amount))
 		}
 	}
 
@@ -152,3 +116,165 @@ func (cc *Controller) ApprovalList(stub shim.ChaincodeStubInterface, params []st
 
 	return shim.Success(response)
 }
+
+// approvalListWithPagination is query fnc
+// params - token name, owner's addresss, page size, bookmark
+// return - a page of approvalList by owner with page metadata
+func (cc *Controller) approvalListWithPagination(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	tokenName, ownerAddress, pageSize, bookmark := params[0], params[1], params[2], params[3]
+
+	pageSizeInt, err := util.ConvertToPageSize("page size", pageSize)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// get a page of approval (format is iterator)
+	approvalIterator, metadata, err := stub.GetStateByPartialCompositeKeyWithPagination(repository.ApprovalPrefix, []string{tokenName, ownerAddress}, pageSizeInt, bookmark)
+	if err != nil {
+		return shim.Error("failed to GetStateByPartialCompositeKeyWithPagination for approval iterator error :" + err.Error())
+	}
+	defer approvalIterator.Close()
+
+	page := model.ApprovalPage{Approvals: []model.Approval{}}
+	for approvalIterator.HasNext() {
+		approvalKV, err := approvalIterator.Next()
+		if err != nil {
+			return shim.Error("failed to get next approval, error : " + err.Error())
+		}
+
+		// get sppender address
+		_, address, err := stub.SplitCompositeKey(approvalKV.GetKey())
+		if err != nil {
+			return shim.Error("failed to SplitCompositeKey, error :" + err.Error())
+		}
+
+		// get amount
+		amount, err := util.ConvertToAmount("allowance", string(approvalKV.GetValue()))
+		if err != nil {
+			return shim.Error("failed to get amount, error : " + err.Error())
+		}
+
+		page.Approvals = append(page.Approvals, *model.NewApproval(tokenName, address[2], ownerAddress, amount))
+	}
+	page.Metadata = *model.NewPageMetadata(metadata.GetFetchedRecordsCount(), metadata.GetBookmark())
+
+	response, err := json.Marshal(page)
+	if err != nil {
+		return shim.Error("failed to Marshal approval page, error : " + err.Error())
+	}
+
+	return shim.Success(response)
+}
+
+// holders is query fnc
+// params - token name, page size, bookmark
+// return - a page of addresses with non-zero balance with page metadata
+// zero balances are skipped so a page can hold less than page size holders
+func (cc *Controller) holders(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	tokenName, pageSize, bookmark := params[0], params[1], params[2]
+
+	pageSizeInt, err := util.ConvertToPageSize("page size", pageSize)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// get a page of balance (format is iterator)
+	balanceIterator, metadata, err := stub.GetStateByPartialCompositeKeyWithPagination(repository.BalancePrefix, []string{tokenName}, pageSizeInt, bookmark)
+	if err != nil {
+		return shim.Error("failed to GetStateByPartialCompositeKeyWithPagination for balance iterator error :" + err.Error())
+	}
+	defer balanceIterator.Close()
+
+	page := model.HolderPage{Holders: []model.Holder{}}
+	for balanceIterator.HasNext() {
+		balanceKV, err := balanceIterator.Next()
+		if err != nil {
+			return shim.Error("failed to get next balance, error : " + err.Error())
+		}
+
+		// get holder address
+		_, address, err := stub.SplitCompositeKey(balanceKV.GetKey())
+		if err != nil {
+			return shim.Error("failed to SplitCompositeKey, error :" + err.Error())
+		}
+
+		// balance includes the deltas of delta mode address
+		balance, err := repository.GetBalance(stub, tokenName, address[1], true)
+		if err != nil {
+			return shim.Error("failed to get balance, error : " + err.Error())
+		}
+
+		if balance.Sign() == 0 {
+			continue
+		}
+
+		page.Holders = append(page.Holders, model.Holder{Address: address[1], Balance: balance.String()})
+	}
+	page.Metadata = *model.NewPageMetadata(metadata.GetFetchedRecordsCount(), metadata.GetBookmark())
+
+	response, err := json.Marshal(page)
+	if err != nil {
+		return shim.Error("failed to Marshal holder page, error : " + err.Error())
+	}
+
+	return shim.Success(response)
+}
+
+// balanceHistory is query fnc
+// it covers the base value only, credits of an address in delta mode appear once a debit or compactBalance folds them
+// params - token name, address, page size, bookmark
+// return - modifications of the balance with tx ID, timestamp, value and deletion flag
+func (cc *Controller) balanceHistory(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	tokenName, address, pageSize, bookmark := params[0], params[1], params[2], params[3]
+
+	pageSizeInt, err := util.ConvertToPageSize("page size", pageSize)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	balanceKey, err := repository.CreateBalanceKey(stub, tokenName, address)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	return historyResponse(stub, balanceKey, pageSizeInt, bookmark)
+}
+
+// allowanceHistory is query fnc
+// params - token name, owner's address, spender's address, page size, bookmark
+// return - modifications of the allowance with tx ID, timestamp, value and deletion flag
+func (cc *Controller) allowanceHistory(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	tokenName, ownerAddress, spenderAddress, pageSize, bookmark := params[0], params[1], params[2], params[3], params[4]
+
+	pageSizeInt, err := util.ConvertToPageSize("page size", pageSize)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	approvalKey, err := repository.CreateApprovalKey(stub, tokenName, ownerAddress, spenderAddress)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	return historyResponse(stub, approvalKey, pageSizeInt, bookmark)
+}
+
+// historyResponse marshals a page of key history
+func historyResponse(stub shim.ChaincodeStubInterface, key string, pageSize int32, bookmark string) sc.Response {
+
+	page, err := repository.GetHistory(stub, key, pageSize, bookmark)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	response, err := json.Marshal(page)
+	if err != nil {
+		return shim.Error("failed to Marshal history, error : " + err.Error())
+	}
+
+	return shim.Success(response)
+}
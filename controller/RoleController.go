@@ -0,0 +1,196 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"hyperledger_dapp/identity"
+	"hyperledger_dapp/model"
+	"hyperledger_dapp/repository"
+	"strings"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	sc "github.com/hyperledger/fabric-protos-go/peer"
+)
+
+// grantRole is invoke fnc that grants role to address
+// MINTER and BURNER are granted per token, ADMIN and PAUSER for the whole chaincode
+// only ADMIN can grant role
+// params - role, address, [token name of MINTER or BURNER]
+func (cc *Controller) grantRole(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	role, address, tokenName := params[0], params[1], optionalParam(params, 2)
+
+	err := checkRoleScope(stub, tokenName, role)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	err = repository.GrantRole(stub, tokenName, role, address)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	return shim.Success([]byte("grantRole success"))
+}
+
+// revokeRole is invoke fnc that revokes role from address
+// only ADMIN can revoke role
+// params - role, address, [token name of MINTER or BURNER]
+func (cc *Controller) revokeRole(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	role, address, tokenName := params[0], params[1], optionalParam(params, 2)
+
+	err := revokeRole(stub, tokenName, role, address)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	return shim.Success([]byte("revokeRole success"))
+}
+
+// renounceRole is invoke fnc that removes role from the caller
+// params - role, caller's address, [token name of MINTER or BURNER]
+func (cc *Controller) renounceRole(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	role, callerAddress, tokenName := params[0], params[1], optionalParam(params, 2)
+
+	// caller can renounce only own role
+	err := identity.CheckCaller(stub, callerAddress)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	err = revokeRole(stub, tokenName, role, callerAddress)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	return shim.Success([]byte("renounceRole success"))
+}
+
+// hasRole is query fnc
+// params - role, address, [token name of MINTER or BURNER]
+// return - true if address has role
+func (cc *Controller) hasRole(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	role, address, tokenName := params[0], params[1], optionalParam(params, 2)
+
+	hasRole, err := repository.HasRole(stub, tokenName, role, address)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	hasRoleBytes, err := json.Marshal(hasRole)
+	if err != nil {
+		return shim.Error("failed to Marshal hasRole, error : " + err.Error())
+	}
+
+	return shim.Success(hasRoleBytes)
+}
+
+// getRoleMembers is query fnc
+// params - role, [token name of MINTER or BURNER]
+// return - addresses which have role
+func (cc *Controller) getRoleMembers(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	role, tokenName := params[0], optionalParam(params, 1)
+
+	members, err := repository.GetRoleMembers(stub, tokenName, role)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	membersBytes, err := json.Marshal(members)
+	if err != nil {
+		return shim.Error("failed to Marshal members, error : " + err.Error())
+	}
+
+	return shim.Success(membersBytes)
+}
+
+// checkRoleScope returns error if role is unknown or tokenName does not match the scope of role
+// a role of token needs an existing token, a chaincode wide role takes no token
+func checkRoleScope(stub shim.ChaincodeStubInterface, tokenName, role string) error {
+
+	if !model.IsRole(role) {
+		return model.NewCustomError(model.ConvertErrorType, "role", "unknown role "+role)
+	}
+
+	if !model.IsTokenRole(role) {
+		if tokenName != "" {
+			return model.NewCustomError(model.ConvertErrorType, "role", role+" is not granted per token")
+		}
+		return nil
+	}
+
+	if tokenName == "" {
+		return model.NewCustomError(model.ConvertErrorType, "role", role+" is granted per token, token name is empty")
+	}
+
+	_, err := repository.GetERC20Metadata(stub, tokenName)
+	return err
+}
+
+// revokeRole removes role from address
+// the last ADMIN is kept, otherwise no one could grant roles or create tokens again
+func revokeRole(stub shim.ChaincodeStubInterface, tokenName, role, address string) error {
+
+	err := checkRoleScope(stub, tokenName, role)
+	if err != nil {
+		return err
+	}
+
+	if role == model.AdminRole {
+		admins, err := repository.GetRoleMembers(stub, "", model.AdminRole)
+		if err != nil {
+			return err
+		}
+
+		if len(admins) == 1 && admins[0] == address {
+			return model.NewCustomError(model.AuthorizeErrorType, model.AdminRole, address+" is the last ADMIN")
+		}
+	}
+
+	return repository.RevokeRole(stub, tokenName, role, address)
+}
+
+// requireRole returns the caller's address if the caller has one of roles
+// a role of token is checked for tokenName, otherwise returns authorization error
+func requireRole(stub shim.ChaincodeStubInterface, tokenName string, roles ...string) (string, error) {
+
+	callerAddress, err := identity.GetCallerAddress(stub)
+	if err != nil {
+		return "", err
+	}
+
+	for _, role := range roles {
+		scope := ""
+		if model.IsTokenRole(role) {
+			// a role of token is never held without token
+			if tokenName == "" {
+				continue
+			}
+			scope = tokenName
+		}
+
+		hasRole, err := repository.HasRole(stub, scope, role, callerAddress)
+		if err != nil {
+			return "", err
+		}
+
+		if hasRole {
+			return callerAddress, nil
+		}
+	}
+
+	errMsg := fmt.Sprintf("%s does not have %s role", callerAddress, strings.Join(roles, " or "))
+	if tokenName != "" {
+		errMsg += " of " + tokenName
+	}
+	return "", model.NewCustomError(model.AuthorizeErrorType, strings.Join(roles, ","), errMsg)
+}
+
+// forbidden makes 403 response for authorization error
+func forbidden(err error) sc.Response {
+	return sc.Response{Status: 403, Message: err.Error()}
+}
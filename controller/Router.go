@@ -0,0 +1,257 @@
+package controller
+
+import (
+	"fmt"
+	"hyperledger_dapp/model"
+	"log"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	sc "github.com/hyperledger/fabric-protos-go/peer"
+)
+
+// Logger is the chaincode log the Logging middleware writes to, the peer collects stderr of the chaincode
+var Logger = log.New(os.Stderr, "erc20 ", log.LstdFlags)
+
+// Handler is a fnc of the chaincode
+type Handler func(stub shim.ChaincodeStubInterface, params []string) sc.Response
+
+// Middleware wraps the handler of function
+type Middleware func(function *Function, next Handler) Handler
+
+// Function is a registered fnc and its description
+// params are required, optional params may follow them, repeated params are a group given one or more times
+type Function struct {
+	Name     string   `json:"name"`
+	Params   []string `json:"params"`
+	Optional []string `json:"optional,omitempty"`
+	Repeated []string `json:"repeated,omitempty"`
+	ReadOnly bool     `json:"readOnly"`
+	Roles    []string `json:"roles,omitempty"`
+	Pausable bool     `json:"pausable"`
+	Handler  Handler  `json:"-"`
+}
+
+// Router calls the handler registered for a fnc through the middleware chain
+type Router struct {
+	functions   map[string]*Function
+	middlewares []Middleware
+}
+
+// NewRouter returns router applying middlewares in order, the first one is the outermost
+func NewRouter(middlewares ...Middleware) *Router {
+	return &Router{functions: map[string]*Function{}, middlewares: middlewares}
+}
+
+// Register adds function, registering a name twice is a programming error
+func (router *Router) Register(function Function) {
+
+	if _, ok := router.functions[function.Name]; ok {
+		panic("function " + function.Name + " is already registered")
+	}
+
+	if function.Params == nil {
+		function.Params = []string{}
+	}
+
+	handler := function.Handler
+	for i := len(router.middlewares) - 1; i >= 0; i-- {
+		handler = router.middlewares[i](&function, handler)
+	}
+	function.Handler = handler
+
+	router.functions[function.Name] = &function
+}
+
+// Handle calls the function registered as fnc
+func (router *Router) Handle(stub shim.ChaincodeStubInterface, fnc string, params []string) sc.Response {
+
+	function, ok := router.functions[fnc]
+	if !ok {
+		return sc.Response{Status: 404, Message: "404 Not Found", Payload: nil}
+	}
+
+	return function.Handler(stub, params)
+}
+
+// Functions returns every registered function ordered by name
+func (router *Router) Functions() []Function {
+
+	functions := []Function{}
+	for _, function := range router.functions {
+		functions = append(functions, *function)
+	}
+
+	sort.Slice(functions, func(i, j int) bool {
+		return functions[i].Name < functions[j].Name
+	})
+
+	return functions
+}
+
+// Recover turns a panic of the handler into an error response
+func Recover(function *Function, next Handler) Handler {
+	return func(stub shim.ChaincodeStubInterface, params []string) (res sc.Response) {
+		defer func() {
+			if r := recover(); r != nil {
+				res = shim.Error(fmt.Sprintf("failed to execute %s, error : %v", function.Name, r))
+			}
+		}()
+		return next(stub, params)
+	}
+}
+
+// Logging logs the fnc and the status of its response
+func Logging(function *Function, next Handler) Handler {
+	return func(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+		res := next(stub, params)
+		if res.Status >= shim.ERRORTHRESHOLD {
+			Logger.Printf("%s in tx %s failed with %d : %s", function.Name, stub.GetTxID(), res.Status, res.Message)
+		} else {
+			Logger.Printf("%s in tx %s succeeded", function.Name, stub.GetTxID())
+		}
+		return res
+	}
+}
+
+// ValidateParams rejects params which don't match the params of function
+func ValidateParams(function *Function, next Handler) Handler {
+	return func(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+		if !function.accepts(len(params)) {
+			return shim.Error(function.Name + " takes params " + function.usage())
+		}
+		return next(stub, params)
+	}
+}
+
+// Authorize rejects the caller without one of the roles of function
+// a role of token is checked for the tokenName param of function
+func Authorize(function *Function, next Handler) Handler {
+	if len(function.Roles) == 0 {
+		return next
+	}
+
+	return func(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+		_, err := requireRole(stub, function.tokenName(params), function.Roles...)
+		if err != nil {
+			return forbidden(err)
+		}
+		return next(stub, params)
+	}
+}
+
+// RejectPaused rejects pausable function while token operations are paused
+func RejectPaused(function *Function, next Handler) Handler {
+	if !function.Pausable {
+		return next
+	}
+
+	return func(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+		err := requireNotPaused(stub)
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+		return next(stub, params)
+	}
+}
+
+// ReadOnly gives read only function a stub which rejects every write
+func ReadOnly(function *Function, next Handler) Handler {
+	if !function.ReadOnly {
+		return next
+	}
+
+	return func(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+		return next(&readOnlyStub{ChaincodeStubInterface: stub, fnc: function.Name}, params)
+	}
+}
+
+// accepts returns true if count params match the params of function
+func (function *Function) accepts(count int) bool {
+
+	required := len(function.Params)
+	if len(function.Repeated) > 0 {
+		return count >= required+len(function.Repeated) && (count-required)%len(function.Repeated) == 0
+	}
+
+	return count >= required && count <= required+len(function.Optional)
+}
+
+// tokenName returns the tokenName param of function, empty if function takes none
+func (function *Function) tokenName(params []string) string {
+
+	for i, param := range function.Params {
+		if param == "tokenName" && i < len(params) {
+			return params[i]
+		}
+	}
+
+	return ""
+}
+
+// optionalParam returns the param at index, empty if it is not given
+func optionalParam(params []string, index int) string {
+
+	if index < len(params) {
+		return params[index]
+	}
+
+	return ""
+}
+
+// usage describes the params of function, e.g. "tokenName, owner, [cap]"
+func (function *Function) usage() string {
+
+	usage := append([]string{}, function.Params...)
+	for _, param := range function.Optional {
+		usage = append(usage, "["+param+"]")
+	}
+	if len(function.Repeated) > 0 {
+		usage = append(usage, strings.Join(function.Repeated, ", ")+", ...")
+	}
+
+	if len(usage) == 0 {
+		return "none"
+	}
+	return strings.Join(usage, ", ")
+}
+
+// readOnlyStub rejects the writes of a read only function
+type readOnlyStub struct {
+	shim.ChaincodeStubInterface
+	fnc string
+}
+
+func (stub *readOnlyStub) readOnlyError() error {
+	return model.NewCustomError(model.PutStateErrorType, stub.fnc, "function is read only")
+}
+
+func (stub *readOnlyStub) PutState(key string, value []byte) error {
+	return stub.readOnlyError()
+}
+
+func (stub *readOnlyStub) DelState(key string) error {
+	return stub.readOnlyError()
+}
+
+func (stub *readOnlyStub) PutPrivateData(collection, key string, value []byte) error {
+	return stub.readOnlyError()
+}
+
+func (stub *readOnlyStub) DelPrivateData(collection, key string) error {
+	return stub.readOnlyError()
+}
+
+func (stub *readOnlyStub) SetStateValidationParameter(key string, ep []byte) error {
+	return stub.readOnlyError()
+}
+
+func (stub *readOnlyStub) SetPrivateDataValidationParameter(collection, key string, ep []byte) error {
+	return stub.readOnlyError()
+}
+
+func (stub *readOnlyStub) SetEvent(name string, payload []byte) error {
+	return stub.readOnlyError()
+}
@@ -0,0 +1,151 @@
+package controller
+
+import (
+	"encoding/json"
+	"hyperledger_dapp/model"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	sc "github.com/hyperledger/fabric-protos-go/peer"
+)
+
+var (
+	adminOnly  = []string{model.AdminRole}
+	minterOnly = []string{model.MinterRole}
+	burnerOnly = []string{model.BurnerRole}
+	pauserRole = []string{model.PauserRole, model.AdminRole}
+
+	// params of the fncs a signed request can run as well
+	transferParams     = []string{"tokenName", "caller", "recipient", "amount"}
+	approveParams      = []string{"tokenName", "owner", "spender", "amount"}
+	transferFromParams = []string{"tokenName", "owner", "spender", "recipient", "amount"}
+)
+
+// Routes returns the router of every fnc of the chaincode
+// params, roles and pause are checked by the middlewares only, so the handlers are unexported and reached through the router
+// Init is not routed, it runs once when the chaincode is instantiated or upgraded
+func (cc *Controller) Routes() *Router {
+
+	router := NewRouter(Recover, Logging, ValidateParams, Authorize, RejectPaused, ReadOnly)
+
+	functions := []Function{
+		// token
+		{Name: "createToken", Params: []string{"tokenName", "symbol", "owner", "amount", "decimals"}, Optional: []string{"cap"}, Roles: adminOnly, Handler: cc.createToken},
+		{Name: "listTokens", ReadOnly: true, Handler: cc.listTokens},
+		{Name: "tokenInfo", Params: []string{"tokenName"}, ReadOnly: true, Handler: cc.tokenInfo},
+		{Name: "decimals", Params: []string{"tokenName"}, ReadOnly: true, Handler: cc.decimals},
+		{Name: "setCap", Params: []string{"tokenName", "cap"}, Roles: adminOnly, Handler: cc.setCap},
+		{Name: "cap", Params: []string{"tokenName"}, ReadOnly: true, Handler: cc.cap},
+		{Name: "totalSupply", Params: []string{"tokenName"}, ReadOnly: true, Handler: cc.totalSupply},
+		{Name: "balanceOf", Params: []string{"tokenName", "address"}, ReadOnly: true, Handler: cc.balanceOf},
+		{Name: "allowance", Params: []string{"tokenName", "owner", "spender"}, ReadOnly: true, Handler: cc.allowance},
+		{Name: "approvalList", Params: []string{"tokenName", "owner"}, ReadOnly: true, Handler: cc.approvalList},
+		{Name: "approvalListWithPagination", Params: []string{"tokenName", "owner", "pageSize", "bookmark"}, ReadOnly: true, Handler: cc.approvalListWithPagination},
+		{Name: "holders", Params: []string{"tokenName", "pageSize", "bookmark"}, ReadOnly: true, Handler: cc.holders},
+		{Name: "balanceHistory", Params: []string{"tokenName", "address", "pageSize", "bookmark"}, ReadOnly: true, Handler: cc.balanceHistory},
+		{Name: "allowanceHistory", Params: []string{"tokenName", "owner", "spender", "pageSize", "bookmark"}, ReadOnly: true, Handler: cc.allowanceHistory},
+		{Name: "auditSupply", Params: []string{"tokenName", "largest"}, ReadOnly: true, Roles: adminOnly, Handler: cc.auditSupply},
+
+		// transfer & allowance
+		{Name: "transfer", Params: transferParams, Pausable: true, Handler: cc.transfer},
+		{Name: "approve", Params: approveParams, Pausable: true, Handler: cc.approve},
+		{Name: "transferFrom", Params: transferFromParams, Pausable: true, Handler: cc.transferFrom},
+		{Name: "increaseAllowance", Params: []string{"tokenName", "owner", "spender", "amount"}, Pausable: true, Handler: cc.increaseAllowance},
+		{Name: "decreaseAllowance", Params: []string{"tokenName", "owner", "spender", "amount"}, Pausable: true, Handler: cc.decreaseAllowance},
+		{Name: "transferOtherToken", Params: []string{"chaincodeName", "tokenName", "caller", "recipient", "amount"}, Pausable: true, Handler: cc.transferOtherToken},
+		{Name: "batchTransfer", Params: []string{"tokenName", "caller"}, Repeated: []string{"recipient", "amount"}, Pausable: true, Handler: cc.batchTransfer},
+		{Name: "airdrop", Params: []string{"tokenName", "recipients"}, Roles: adminOnly, Pausable: true, Handler: cc.airdrop},
+		{Name: "mint", Params: []string{"tokenName", "recipient", "amount"}, Roles: minterOnly, Pausable: true, Handler: cc.mint},
+		{Name: "burn", Params: []string{"tokenName", "caller", "amount"}, Pausable: true, Handler: cc.burn},
+		{Name: "burnFrom", Params: []string{"tokenName", "owner", "spender", "amount"}, Roles: burnerOnly, Pausable: true, Handler: cc.burnFrom},
+
+		// signing
+		{Name: "registerSigningKey", Params: []string{"caller"}, Handler: cc.registerSigningKey},
+		{Name: "permit", Params: []string{"tokenName", "owner", "spender", "amount", "nonce", "deadline", "signature"}, Pausable: true, Handler: cc.permit},
+		{Name: "nonces", Params: []string{"address"}, ReadOnly: true, Handler: cc.nonces},
+		{Name: "bindSigningKey", Params: []string{"address", "curve", "publicKey", "signature"}, Handler: cc.bindSigningKey},
+		{Name: "executeSigned", Params: []string{"request", "curve", "publicKey", "signature"}, Pausable: true, Handler: cc.executeSigned},
+		{Name: "signingKeyOf", Params: []string{"address"}, ReadOnly: true, Handler: cc.signingKeyOf},
+
+		// htlc & vesting
+		{Name: "htlcLock", Params: []string{"tokenName", "sender", "recipient", "amount", "hashlock", "timelock"}, Pausable: true, Handler: cc.htlcLock},
+		{Name: "htlcClaim", Params: []string{"lockId", "preimage"}, Pausable: true, Handler: cc.htlcClaim},
+		{Name: "htlcRefund", Params: []string{"lockId"}, Pausable: true, Handler: cc.htlcRefund},
+		{Name: "htlcLocks", Params: []string{"address"}, ReadOnly: true, Handler: cc.htlcLocks},
+		{Name: "createVesting", Params: []string{"tokenName", "grantor", "beneficiary", "total", "start", "cliff", "duration", "revocable"}, Pausable: true, Handler: cc.createVesting},
+		{Name: "releaseVested", Params: []string{"vestingId"}, Pausable: true, Handler: cc.releaseVested},
+		{Name: "revokeVesting", Params: []string{"vestingId"}, Pausable: true, Handler: cc.revokeVesting},
+		{Name: "vestingInfo", Params: []string{"vestingId"}, ReadOnly: true, Handler: cc.vestingInfo},
+
+		// snapshot & dividend
+		{Name: "snapshot", Params: []string{"tokenName"}, Roles: adminOnly, Handler: cc.snapshot},
+		{Name: "balanceOfAt", Params: []string{"tokenName", "address", "snapshotId"}, ReadOnly: true, Handler: cc.balanceOfAt},
+		{Name: "totalSupplyAt", Params: []string{"tokenName", "snapshotId"}, ReadOnly: true, Handler: cc.totalSupplyAt},
+		{Name: "createDistribution", Params: []string{"tokenName", "snapshotId", "payoutToken", "payer", "amount"}, Optional: []string{"deadline"}, Roles: adminOnly, Pausable: true, Handler: cc.createDistribution},
+		{Name: "claimDividend", Params: []string{"distributionId", "holder"}, Pausable: true, Handler: cc.claimDividend},
+		{Name: "setDividendDeadline", Params: []string{"distributionId", "deadline"}, Pausable: true, Handler: cc.setDividendDeadline},
+		{Name: "reclaimDividend", Params: []string{"distributionId"}, Pausable: true, Handler: cc.reclaimDividend},
+		{Name: "unclaimedDividend", Params: []string{"distributionId", "holder"}, ReadOnly: true, Handler: cc.unclaimedDividend},
+		{Name: "distributionInfo", Params: []string{"distributionId"}, ReadOnly: true, Handler: cc.distributionInfo},
+
+		// governance & votes
+		{Name: "setGovernanceConfig", Params: []string{"tokenName", "quorum", "threshold", "proposalThreshold"}, Roles: adminOnly, Handler: cc.setGovernanceConfig},
+		{Name: "governanceConfig", Params: []string{"tokenName"}, ReadOnly: true, Handler: cc.governanceConfig},
+		{Name: "setGovernanceToken", Params: []string{"tokenName"}, Roles: adminOnly, Handler: cc.setGovernanceToken},
+		{Name: "governanceToken", ReadOnly: true, Handler: cc.governanceToken},
+		{Name: "propose", Params: []string{"tokenName", "proposer", "description", "actions", "votingPeriod"}, Handler: cc.propose},
+		{Name: "castVote", Params: []string{"proposalId", "voter", "support"}, Handler: cc.castVote},
+		{Name: "tallyProposal", Params: []string{"proposalId"}, Handler: cc.tallyProposal},
+		{Name: "executeProposal", Params: []string{"proposalId"}, Handler: cc.executeProposal},
+		{Name: "proposalInfo", Params: []string{"proposalId"}, ReadOnly: true, Handler: cc.proposalInfo},
+		{Name: "delegate", Params: []string{"tokenName", "delegator", "delegatee"}, Handler: cc.delegate},
+		{Name: "delegates", Params: []string{"tokenName", "address"}, ReadOnly: true, Handler: cc.delegates},
+		{Name: "getVotes", Params: []string{"tokenName", "address"}, ReadOnly: true, Handler: cc.getVotes},
+		{Name: "getPastVotes", Params: []string{"tokenName", "address", "timestamp"}, ReadOnly: true, Handler: cc.getPastVotes},
+
+		// fee & delta mode
+		{Name: "setFeeConfig", Params: []string{"tokenName", "basisPoints", "minimumFee", "maximumFee", "treasury"}, Roles: adminOnly, Handler: cc.setFeeConfig},
+		{Name: "setFeeExempt", Params: []string{"tokenName", "address", "exempt"}, Roles: adminOnly, Handler: cc.setFeeExempt},
+		{Name: "feeConfig", Params: []string{"tokenName"}, ReadOnly: true, Handler: cc.feeConfig},
+		{Name: "listFeeExempt", Params: []string{"tokenName"}, ReadOnly: true, Handler: cc.listFeeExempt},
+		{Name: "quoteFee", Params: []string{"tokenName", "amount", "sender", "recipient"}, ReadOnly: true, Handler: cc.quoteFee},
+		{Name: "setDeltaMode", Params: []string{"tokenName", "address", "enabled"}, Roles: adminOnly, Handler: cc.setDeltaMode},
+		{Name: "deltaMode", Params: []string{"tokenName", "address"}, ReadOnly: true, Handler: cc.deltaMode},
+		{Name: "compactBalance", Params: []string{"tokenName", "address"}, Handler: cc.compactBalance},
+
+		// role, pause & freeze
+		{Name: "grantRole", Params: []string{"role", "address"}, Optional: []string{"tokenName"}, Roles: adminOnly, Handler: cc.grantRole},
+		{Name: "revokeRole", Params: []string{"role", "address"}, Optional: []string{"tokenName"}, Roles: adminOnly, Handler: cc.revokeRole},
+		{Name: "renounceRole", Params: []string{"role", "caller"}, Optional: []string{"tokenName"}, Handler: cc.renounceRole},
+		{Name: "hasRole", Params: []string{"role", "address"}, Optional: []string{"tokenName"}, ReadOnly: true, Handler: cc.hasRole},
+		{Name: "getRoleMembers", Params: []string{"role"}, Optional: []string{"tokenName"}, ReadOnly: true, Handler: cc.getRoleMembers},
+		{Name: "pause", Roles: pauserRole, Handler: cc.pause},
+		{Name: "unpause", Roles: pauserRole, Handler: cc.unpause},
+		{Name: "paused", ReadOnly: true, Handler: cc.paused},
+		{Name: "freezeAccount", Params: []string{"address"}, Roles: adminOnly, Handler: cc.freezeAccount},
+		{Name: "unfreezeAccount", Params: []string{"address"}, Roles: adminOnly, Handler: cc.unfreezeAccount},
+		{Name: "isFrozen", Params: []string{"address"}, ReadOnly: true, Handler: cc.isFrozen},
+		{Name: "listFrozen", ReadOnly: true, Handler: cc.listFrozen},
+	}
+
+	for _, function := range functions {
+		router.Register(function)
+	}
+
+	router.Register(Function{Name: "listFunctions", ReadOnly: true, Handler: listFunctions(router)})
+
+	return router
+}
+
+// listFunctions is query fnc
+// return - every registered function without its handler
+func listFunctions(router *Router) Handler {
+	return func(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+		functionsBytes, err := json.Marshal(router.Functions())
+		if err != nil {
+			return shim.Error("failed to Marshal functions, error : " + err.Error())
+		}
+
+		return shim.Success(functionsBytes)
+	}
+}
@@ -0,0 +1,114 @@
+package controller
+
+import (
+	"hyperledger_dapp/model"
+	"hyperledger_dapp/repository"
+	"strconv"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	sc "github.com/hyperledger/fabric-protos-go/peer"
+)
+
+// snapshot is invoke fnc that takes a snapshot of balances and total supply
+// only ADMIN can take snapshot
+// params - token name
+// return - snapshot id
+func (cc *Controller) snapshot(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	tokenName := params[0]
+
+	_, err := repository.GetERC20Metadata(stub, tokenName)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	id, err := takeSnapshot(stub, tokenName)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	return shim.Success([]byte(strconv.FormatInt(id, 10)))
+}
+
+// balanceOfAt is query fnc
+// params - token name, address, snapshot id
+// return - balance of address at the snapshot
+func (cc *Controller) balanceOfAt(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	tokenName, address := params[0], params[1]
+
+	id, err := convertToSnapshotID(stub, tokenName, params[2])
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	balance, err := repository.GetBalanceAt(stub, tokenName, address, id)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	return shim.Success([]byte(balance.String()))
+}
+
+// totalSupplyAt is query fnc
+// params - token name, snapshot id
+// return - total supply at the snapshot
+func (cc *Controller) totalSupplyAt(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	tokenName := params[0]
+
+	id, err := convertToSnapshotID(stub, tokenName, params[1])
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	totalSupply, err := repository.GetTotalSupplyAt(stub, tokenName, id)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	return shim.Success([]byte(totalSupply.String()))
+}
+
+// convertToSnapshotID returns error if value is not a snapshot id taken of token
+func convertToSnapshotID(stub shim.ChaincodeStubInterface, tokenName, value string) (int64, error) {
+
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || id <= 0 {
+		return 0, model.NewCustomError(model.ConvertErrorType, "snapshot id", value+" is not a snapshot id")
+	}
+
+	currentID, err := repository.GetSnapshotID(stub, tokenName)
+	if err != nil {
+		return 0, err
+	}
+
+	if id > currentID {
+		return 0, model.NewCustomError(model.ConvertErrorType, "snapshot id", value+" is not taken yet")
+	}
+
+	return id, nil
+}
+
+// takeSnapshot increases the snapshot id of token and emits snapshot event
+// values are recorded lazily on the first change after the snapshot
+func takeSnapshot(stub shim.ChaincodeStubInterface, tokenName string) (int64, error) {
+
+	id, err := repository.GetSnapshotID(stub, tokenName)
+	if err != nil {
+		return 0, err
+	}
+	id++
+
+	err = repository.SaveSnapshotID(stub, tokenName, id)
+	if err != nil {
+		return 0, err
+	}
+
+	err = repository.EmitSnapshotEvent(stub, tokenName, id)
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
@@ -0,0 +1,156 @@
+package controller
+
+import (
+	"encoding/json"
+	"hyperledger_dapp/model"
+	"hyperledger_dapp/repository"
+	"hyperledger_dapp/util"
+	"math/big"
+	"strconv"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	sc "github.com/hyperledger/fabric-protos-go/peer"
+)
+
+// createToken is invoke fnc that registers a new token in the chaincode
+// only ADMIN can create token
+// params - token name, symbol, owner's address, amount of initial supply, decimals, (optional) cap
+func (cc *Controller) createToken(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	tokenName, symbol, owner, amount, decimals := params[0], params[1], params[2], params[3], params[4]
+	capAmount := ""
+	if len(params) == 6 {
+		capAmount = params[5]
+	}
+
+	err := createToken(stub, tokenName, symbol, owner, amount, decimals, capAmount)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	return shim.Success([]byte("createToken success"))
+}
+
+// listTokens is query fnc
+// return - metadata of every registered token
+func (cc *Controller) listTokens(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	metadataSlice, err := repository.ListERC20Metadata(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	response, err := json.Marshal(metadataSlice)
+	if err != nil {
+		return shim.Error("failed to Marshal metadataSlice, error : " + err.Error())
+	}
+
+	return shim.Success(response)
+}
+
+// tokenInfo is query fnc
+// params - token name
+// return - metadata of token
+func (cc *Controller) tokenInfo(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	tokenName := params[0]
+
+	metadata, err := repository.GetERC20Metadata(stub, tokenName)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	response, err := json.Marshal(metadata)
+	if err != nil {
+		return shim.Error("failed to Marshal metadata, error : " + err.Error())
+	}
+
+	return shim.Success(response)
+}
+
+// decimals is query fnc
+// params - token name
+// return - the number of decimals used for display
+func (cc *Controller) decimals(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	tokenName := params[0]
+
+	metadata, err := repository.GetERC20Metadata(stub, tokenName)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	return shim.Success([]byte(strconv.Itoa(int(*metadata.GetDecimals()))))
+}
+
+// setCap is invoke fnc that caps a token which has no cap yet
+// only ADMIN can set cap, and cap can be set only once
+// params - token name, cap
+func (cc *Controller) setCap(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	tokenName, capAmount := params[0], params[1]
+
+	capInt, err := util.ConvertToPositive("cap", capAmount)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	metadata, err := repository.GetERC20Metadata(stub, tokenName)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	curCap, err := metadata.GetCap()
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// cap is never raised or replaced
+	if curCap != nil {
+		return shim.Error(tokenName + " is already capped")
+	}
+
+	if metadata.GetTotalSupply().Cmp(capInt) > 0 {
+		return shim.Error("cap cannot be less than total supply")
+	}
+
+	metadata.SetCap(capInt)
+	err = repository.SaveERC20Metadata(stub, metadata)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	return shim.Success([]byte("setCap success"))
+}
+
+// cap is query fnc
+// params - token name
+// return - cap, total supply and remaining mintable supply, empty cap if the token is not capped
+func (cc *Controller) cap(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	tokenName := params[0]
+
+	metadata, err := repository.GetERC20Metadata(stub, tokenName)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	capAmount, err := metadata.GetCap()
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	totalSupply := metadata.GetTotalSupply()
+	capInfo := model.CapInfo{TotalSupply: totalSupply.String()}
+	if capAmount != nil {
+		capInfo.Cap = capAmount.String()
+		capInfo.Remaining = new(big.Int).Sub(capAmount, totalSupply).String()
+	}
+
+	response, err := json.Marshal(capInfo)
+	if err != nil {
+		return shim.Error("failed to Marshal capInfo, error : " + err.Error())
+	}
+
+	return shim.Success(response)
+}
@@ -0,0 +1,247 @@
+package controller
+
+import (
+	"encoding/json"
+	"hyperledger_dapp/identity"
+	"hyperledger_dapp/model"
+	"hyperledger_dapp/repository"
+	"hyperledger_dapp/util"
+	"strconv"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	sc "github.com/hyperledger/fabric-protos-go/peer"
+)
+
+// vestingEscrow is the account holding the locked tokens of vesting schedules
+var vestingEscrow = identity.EscrowAddress("vesting")
+
+// createVesting is invoke fnc that locks total token of grantor for beneficiary
+// params - token name, grantor's address, beneficiary's address, total, start(unix seconds),
+// cliff(seconds from start), duration(seconds from start), revocable(true or false)
+// return - vesting id
+func (cc *Controller) createVesting(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	tokenName, grantorAddress, beneficiaryAddress, total := params[0], params[1], params[2], params[3]
+
+	totalInt, err := util.ConvertToPositive("vesting total", total)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	start, err := strconv.ParseInt(params[4], 10, 64)
+	if err != nil {
+		return shim.Error("start must be unix seconds")
+	}
+
+	cliff, err := strconv.ParseInt(params[5], 10, 64)
+	if err != nil || cliff < 0 {
+		return shim.Error("cliff must be non-negative seconds")
+	}
+
+	duration, err := strconv.ParseInt(params[6], 10, 64)
+	if err != nil || duration <= 0 {
+		return shim.Error("duration must be positive seconds")
+	}
+
+	if cliff > duration {
+		return shim.Error("cliff cannot be longer than duration")
+	}
+
+	revocable, err := strconv.ParseBool(params[7])
+	if err != nil {
+		return shim.Error("revocable must be true or false")
+	}
+
+	// frozen accounts cannot send or receive
+	err = requireNotFrozen(stub, grantorAddress, beneficiaryAddress)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// grantor must be the submitter
+	err = identity.CheckCaller(stub, grantorAddress)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	vesting := model.NewVesting(newRecordID(stub), tokenName, grantorAddress, beneficiaryAddress, totalInt, start, cliff, duration, revocable)
+
+	// locked amount is debited from grantor
+	err = transfer(stub, tokenName, grantorAddress, vestingEscrow, totalInt)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	err = repository.SaveVesting(stub, vesting)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	return shim.Success([]byte(vesting.ID))
+}
+
+// releaseVested is invoke fnc that pays the vested and unreleased token to beneficiary
+// anyone can submit it
+// params - vesting id
+func (cc *Controller) releaseVested(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	id := params[0]
+
+	vesting, err := repository.GetVesting(stub, id)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	err = requireNotFrozen(stub, vesting.Beneficiary)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	timestamp, err := stub.GetTxTimestamp()
+	if err != nil {
+		return shim.Error("failed to GetTxTimestamp, error : " + err.Error())
+	}
+
+	releasable, err := vesting.ReleasableAmount(timestamp.GetSeconds())
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	if releasable.Sign() <= 0 {
+		return shim.Error("vesting " + id + " has nothing to release")
+	}
+
+	err = transfer(stub, vesting.Token, vestingEscrow, vesting.Beneficiary, releasable)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	curReleased, err := vesting.GetReleased()
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	released, err := util.AddAmount("released", curReleased, releasable)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	vesting.SetReleased(released)
+
+	err = repository.SaveVesting(stub, vesting)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	return shim.Success([]byte(releasable.String()))
+}
+
+// revokeVesting is invoke fnc that returns the unvested token to grantor
+// the vested amount stays releasable to beneficiary
+// only grantor can revoke a revocable schedule
+// params - vesting id
+func (cc *Controller) revokeVesting(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	id := params[0]
+
+	vesting, err := repository.GetVesting(stub, id)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// grantor must be the submitter
+	err = identity.CheckCaller(stub, vesting.Grantor)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	if !vesting.Revocable {
+		return shim.Error("vesting " + id + " is not revocable")
+	}
+
+	if vesting.Revoked {
+		return shim.Error("vesting " + id + " is already revoked")
+	}
+
+	err = requireNotFrozen(stub, vesting.Grantor)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	timestamp, err := stub.GetTxTimestamp()
+	if err != nil {
+		return shim.Error("failed to GetTxTimestamp, error : " + err.Error())
+	}
+
+	total, err := vesting.GetTotal()
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	vested, err := vesting.VestedAmount(timestamp.GetSeconds())
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	refund, err := util.SubAmount("unvested", total, vested)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	if refund.Sign() > 0 {
+		err = transfer(stub, vesting.Token, vestingEscrow, vesting.Grantor, refund)
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+	}
+
+	vesting.Revoked = true
+	vesting.SetRefunded(refund)
+
+	err = repository.SaveVesting(stub, vesting)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	return shim.Success([]byte(refund.String()))
+}
+
+// vestingInfo is query fnc
+// params - vesting id
+// return - vesting schedule with vested and releasable amount at the transaction timestamp
+func (cc *Controller) vestingInfo(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	id := params[0]
+
+	vesting, err := repository.GetVesting(stub, id)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	timestamp, err := stub.GetTxTimestamp()
+	if err != nil {
+		return shim.Error("failed to GetTxTimestamp, error : " + err.Error())
+	}
+
+	vested, err := vesting.VestedAmount(timestamp.GetSeconds())
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	releasable, err := vesting.ReleasableAmount(timestamp.GetSeconds())
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	vestingInfo := model.VestingInfo{
+		Vesting:    *vesting,
+		Vested:     vested.String(),
+		Releasable: releasable.String(),
+	}
+
+	vestingInfoBytes, err := json.Marshal(vestingInfo)
+	if err != nil {
+		return shim.Error("failed to Marshal vestingInfo, error : " + err.Error())
+	}
+
+	return shim.Success(vestingInfoBytes)
+}
@@ -0,0 +1,173 @@
+package controller
+
+import (
+	"hyperledger_dapp/identity"
+	"hyperledger_dapp/repository"
+	"hyperledger_dapp/util"
+	"math/big"
+	"strconv"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	sc "github.com/hyperledger/fabric-protos-go/peer"
+)
+
+// delegate is invoke fnc that moves the voting power of delegator to delegatee
+// balance counts as votes only once it is delegated, delegate to self to vote with own balance
+// params - token name, delegator's address, delegatee's address
+func (cc *Controller) delegate(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	tokenName, delegatorAddress, delegateeAddress := params[0], params[1], params[2]
+
+	// delegator must be the submitter
+	err := identity.CheckCaller(stub, delegatorAddress)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	curDelegatee, err := repository.GetDelegate(stub, tokenName, delegatorAddress)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	balance, err := repository.GetBalance(stub, tokenName, delegatorAddress, true)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	err = repository.SaveDelegate(stub, tokenName, delegatorAddress, delegateeAddress)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	err = moveDelegateVotes(stub, tokenName, curDelegatee, delegateeAddress, balance)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	return shim.Success([]byte("delegate success"))
+}
+
+// delegates is query fnc
+// params - token name, address
+// return - delegatee of address, empty if address did not delegate
+func (cc *Controller) delegates(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	delegatee, err := repository.GetDelegate(stub, params[0], params[1])
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	return shim.Success([]byte(delegatee))
+}
+
+// getVotes is query fnc
+// params - token name, address
+// return - current votes delegated to address
+func (cc *Controller) getVotes(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	votes, err := repository.GetVotes(stub, params[0], params[1])
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	return shim.Success([]byte(votes.String()))
+}
+
+// getPastVotes is query fnc
+// params - token name, address, timestamp(unix seconds, before the transaction timestamp)
+// return - votes delegated to address at timestamp
+func (cc *Controller) getPastVotes(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	tokenName, address := params[0], params[1]
+
+	timestamp, err := strconv.ParseInt(params[2], 10, 64)
+	if err != nil {
+		return shim.Error("timestamp must be unix seconds")
+	}
+
+	txTimestamp, err := stub.GetTxTimestamp()
+	if err != nil {
+		return shim.Error("failed to GetTxTimestamp, error : " + err.Error())
+	}
+
+	// votes of the current second can still change
+	if timestamp >= txTimestamp.GetSeconds() {
+		return shim.Error("timestamp must be before the transaction timestamp")
+	}
+
+	votes, err := repository.GetPastVotes(stub, tokenName, address, timestamp)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	return shim.Success([]byte(votes.String()))
+}
+
+// moveVotingPower moves the votes of the delegatee of each address by its balance change
+// every balance change calls it, balance of an address which did not delegate is not counted
+func moveVotingPower(stub shim.ChaincodeStubInterface, tokenName string, changes balanceChanges) error {
+
+	votesChanges := balanceChanges{}
+	for _, address := range changes.addresses() {
+		delegatee, err := repository.GetDelegate(stub, tokenName, address)
+		if err != nil {
+			return err
+		}
+
+		votesChanges.add(delegatee, changes[address])
+	}
+
+	return applyVotesChanges(stub, tokenName, votesChanges)
+}
+
+// moveDelegateVotes moves votes of amount between delegatees
+func moveDelegateVotes(stub shim.ChaincodeStubInterface, tokenName, srcDelegatee, dstDelegatee string, amount *big.Int) error {
+
+	votesChanges := balanceChanges{}
+	votesChanges.add(srcDelegatee, new(big.Int).Neg(amount))
+	votesChanges.add(dstDelegatee, amount)
+
+	return applyVotesChanges(stub, tokenName, votesChanges)
+}
+
+// applyVotesChanges saves the votes of each delegatee and checkpoints them at the transaction timestamp
+func applyVotesChanges(stub shim.ChaincodeStubInterface, tokenName string, votesChanges balanceChanges) error {
+
+	var timestamp int64
+	for _, delegatee := range votesChanges.addresses() {
+		delta := votesChanges[delegatee]
+		if delegatee == "" || delegatee == identity.ZeroAddress || delta.Sign() == 0 {
+			continue
+		}
+
+		if timestamp == 0 {
+			txTimestamp, err := stub.GetTxTimestamp()
+			if err != nil {
+				return err
+			}
+			timestamp = txTimestamp.GetSeconds()
+		}
+
+		votes, err := repository.GetVotes(stub, tokenName, delegatee)
+		if err != nil {
+			return err
+		}
+
+		var resultVotes *big.Int
+		if delta.Sign() > 0 {
+			resultVotes, err = util.AddAmount("votes", votes, delta)
+		} else {
+			resultVotes, err = util.SubAmount("votes", votes, new(big.Int).Neg(delta))
+		}
+		if err != nil {
+			return err
+		}
+
+		err = repository.SaveVotes(stub, tokenName, delegatee, resultVotes, timestamp)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
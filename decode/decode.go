@@ -0,0 +1,101 @@
+// Package decode parses the events emitted by the chaincode for off-chain consumers
+package decode
+
+import (
+	"encoding/json"
+	"fmt"
+	"hyperledger_dapp/model"
+	"hyperledger_dapp/repository"
+)
+
+// Envelope parses the payload of an envelope event
+func Envelope(payload []byte) (*model.EventEnvelope, error) {
+
+	envelope := &model.EventEnvelope{}
+	err := json.Unmarshal(payload, envelope)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode envelope, error : %s", err.Error())
+	}
+
+	if envelope.Version != model.EventEnvelopeVersion {
+		return nil, fmt.Errorf("failed to decode envelope, error : unsupported version %d", envelope.Version)
+	}
+
+	return envelope, nil
+}
+
+// Events returns the events of a chaincode event
+// events emitted before the envelope are returned as a single record
+func Events(eventName string, payload []byte) ([]model.EventRecord, error) {
+
+	if eventName != repository.EnvelopeEventKey {
+		return []model.EventRecord{{Name: eventName, Payload: payload}}, nil
+	}
+
+	envelope, err := Envelope(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return envelope.Events, nil
+}
+
+// Transfer decodes a transferEvent record
+func Transfer(record model.EventRecord) (*model.TransferEvent, error) {
+	transferEvent := &model.TransferEvent{}
+	return transferEvent, decodeRecord(record, transferEvent, repository.TransferEventKey)
+}
+
+// Approval decodes an approvalEvent record
+func Approval(record model.EventRecord) (*model.Approval, error) {
+	approval := &model.Approval{}
+	return approval, decodeRecord(record, approval, repository.ApprovalEventKey)
+}
+
+// Pause decodes a pauseEvent or unpauseEvent record
+func Pause(record model.EventRecord) (*model.PauseEvent, error) {
+	pauseEvent := &model.PauseEvent{}
+	return pauseEvent, decodeRecord(record, pauseEvent, repository.PauseEventKey, repository.UnpauseEventKey)
+}
+
+// Freeze decodes a freezeEvent or unfreezeEvent record
+func Freeze(record model.EventRecord) (*model.FreezeEvent, error) {
+	freezeEvent := &model.FreezeEvent{}
+	return freezeEvent, decodeRecord(record, freezeEvent, repository.FreezeEventKey, repository.UnfreezeEventKey)
+}
+
+// HTLC decodes an htlcLockEvent, htlcClaimEvent or htlcRefundEvent record
+func HTLC(record model.EventRecord) (*model.HTLC, error) {
+	htlc := &model.HTLC{}
+	return htlc, decodeRecord(record, htlc, repository.HTLCLockEventKey, repository.HTLCClaimEventKey, repository.HTLCRefundEventKey)
+}
+
+// Snapshot decodes a snapshotEvent record
+func Snapshot(record model.EventRecord) (*model.SnapshotEvent, error) {
+	snapshotEvent := &model.SnapshotEvent{}
+	return snapshotEvent, decodeRecord(record, snapshotEvent, repository.SnapshotEventKey)
+}
+
+// BatchTransfer decodes a batchTransferEvent or airdropEvent record
+func BatchTransfer(record model.EventRecord) (*model.BatchTransferEvent, error) {
+	batchTransferEvent := &model.BatchTransferEvent{}
+	return batchTransferEvent, decodeRecord(record, batchTransferEvent, repository.BatchTransferEventKey, repository.AirdropEventKey)
+}
+
+// decodeRecord unmarshals payload of record into v if record has one of names
+func decodeRecord(record model.EventRecord, v interface{}, names ...string) error {
+
+	for _, name := range names {
+		if record.Name != name {
+			continue
+		}
+
+		err := json.Unmarshal(record.Payload, v)
+		if err != nil {
+			return fmt.Errorf("failed to decode %s, error : %s", record.Name, err.Error())
+		}
+		return nil
+	}
+
+	return fmt.Errorf("failed to decode %s, error : unexpected event", record.Name)
+}
@@ -3,10 +3,13 @@ module hyperledger_dapp
 go 1.13
 
 require (
+	github.com/decred/dcrd/dcrec/secp256k1/v3 v3.0.0
+	github.com/golang/protobuf v1.3.2
 	github.com/hyperledger/fabric-chaincode-go v0.0.0-20200424173110-d7076418f212
 	github.com/hyperledger/fabric-protos-go v0.0.0-20200424173316-dd554ba3746e
 	github.com/kr/pretty v0.2.0 // indirect
 	github.com/stretchr/testify v1.8.0 // indirect
+	go.etcd.io/bbolt v1.3.6
 	golang.org/x/net v0.0.0-20190827160401-ba9fcec4b297 // indirect
 	golang.org/x/text v0.3.2 // indirect
 	gopkg.in/check.v1 v1.0.0-20190902080502-41f04d3bba15 // indirect
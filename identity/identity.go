@@ -0,0 +1,157 @@
+package identity
+
+import (
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"encoding/hex"
+	"hyperledger_dapp/model"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-chaincode-go/pkg/cid"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-protos-go/common"
+	sc "github.com/hyperledger/fabric-protos-go/peer"
+)
+
+// AddressLength is the number of bytes of the identity hash used as account address
+const AddressLength = 20
+
+// ZeroAddress is the sender of minted tokens and the recipient of burned tokens
+const ZeroAddress = "0000000000000000000000000000000000000000"
+
+// ToAddress maps MSP ID & certificate ID to a stable account address
+// address is hex(sha256(mspID + "::" + id)[:20])
+func ToAddress(mspID, id string) string {
+	hash := sha256.Sum256([]byte(mspID + "::" + id))
+	return hex.EncodeToString(hash[:AddressLength])
+}
+
+// AddressOfKey maps a public key of a holder without Fabric identity to a stable account address
+// address is hex(sha256("key::" + curve + "::" + publicKeyHex)[:20])
+func AddressOfKey(curve, publicKeyHex string) string {
+	hash := sha256.Sum256([]byte("key::" + curve + "::" + publicKeyHex))
+	return hex.EncodeToString(hash[:AddressLength])
+}
+
+// EscrowAddress maps a chaincode module to the account holding its escrowed tokens
+// address is hex(sha256("escrow::" + module)[:20]), nobody holds its certificate
+func EscrowAddress(module string) string {
+	hash := sha256.Sum256([]byte("escrow::" + module))
+	return hex.EncodeToString(hash[:AddressLength])
+}
+
+// GetCallerAddress returns the account address of the transaction submitter
+func GetCallerAddress(stub shim.ChaincodeStubInterface) (string, error) {
+
+	// parse the submitter's X.509 identity
+	clientID, err := cid.New(stub)
+	if err != nil {
+		return "", model.NewCustomError(model.IdentifyErrorType, "caller", err.Error())
+	}
+
+	mspID, err := clientID.GetMSPID()
+	if err != nil {
+		return "", model.NewCustomError(model.IdentifyErrorType, "mspID", err.Error())
+	}
+
+	// subject & issuer of the certificate (stays the same on renewal)
+	id, err := clientID.GetID()
+	if err != nil {
+		return "", model.NewCustomError(model.IdentifyErrorType, "certificate", err.Error())
+	}
+
+	return ToAddress(mspID, id), nil
+}
+
+// CheckCaller returns error if the claimed address is not the submitter's address
+func CheckCaller(stub shim.ChaincodeStubInterface, claimedAddress string) error {
+
+	callerAddress, err := GetCallerAddress(stub)
+	if err != nil {
+		return err
+	}
+
+	return CheckAddress(callerAddress, claimedAddress)
+}
+
+// CheckAddress returns error if the claimed address is not the address of the acting account
+func CheckAddress(actingAddress, claimedAddress string) error {
+
+	if actingAddress != claimedAddress {
+		return model.NewCustomError(model.AuthenticateErrorType, claimedAddress, "address does not match the submitter "+actingAddress)
+	}
+
+	return nil
+}
+
+// GetCallerPublicKey returns the ECDSA public key of the submitter's certificate
+func GetCallerPublicKey(stub shim.ChaincodeStubInterface) (*ecdsa.PublicKey, error) {
+
+	cert, err := cid.GetX509Certificate(stub)
+	if err != nil {
+		return nil, model.NewCustomError(model.IdentifyErrorType, "certificate", err.Error())
+	}
+
+	if cert == nil {
+		return nil, model.NewCustomError(model.IdentifyErrorType, "certificate", "caller has no X.509 certificate")
+	}
+
+	publicKey, ok := cert.PublicKey.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, model.NewCustomError(model.IdentifyErrorType, "certificate", "caller's key is not ECDSA")
+	}
+
+	return publicKey, nil
+}
+
+// GetChaincodeName returns the name of the chaincode the proposal invokes
+// the name is read from the chaincode header extension of the signed proposal
+func GetChaincodeName(stub shim.ChaincodeStubInterface) (string, error) {
+
+	proposal, err := getProposal(stub)
+	if err != nil {
+		return "", err
+	}
+
+	header := &common.Header{}
+	err = proto.Unmarshal(proposal.GetHeader(), header)
+	if err != nil {
+		return "", model.NewCustomError(model.UnmarshalErrorType, "header", err.Error())
+	}
+
+	channelHeader := &common.ChannelHeader{}
+	err = proto.Unmarshal(header.GetChannelHeader(), channelHeader)
+	if err != nil {
+		return "", model.NewCustomError(model.UnmarshalErrorType, "channel header", err.Error())
+	}
+
+	extension := &sc.ChaincodeHeaderExtension{}
+	err = proto.Unmarshal(channelHeader.GetExtension(), extension)
+	if err != nil {
+		return "", model.NewCustomError(model.UnmarshalErrorType, "chaincode header extension", err.Error())
+	}
+
+	name := extension.GetChaincodeId().GetName()
+	if name == "" {
+		return "", model.NewCustomError(model.IdentifyErrorType, "chaincode", "proposal has no chaincode name")
+	}
+
+	return name, nil
+}
+
+// getProposal returns the proposal of the transaction
+func getProposal(stub shim.ChaincodeStubInterface) (*sc.Proposal, error) {
+
+	signedProposal, err := stub.GetSignedProposal()
+	if err != nil || signedProposal == nil {
+		return nil, model.NewCustomError(model.IdentifyErrorType, "chaincode", "signed proposal is not available")
+	}
+
+	proposal := &sc.Proposal{}
+	err = proto.Unmarshal(signedProposal.GetProposalBytes(), proposal)
+	if err != nil {
+		return nil, model.NewCustomError(model.UnmarshalErrorType, "proposal", err.Error())
+	}
+
+	return proposal, nil
+}
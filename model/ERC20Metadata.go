@@ -1,24 +1,63 @@
 package model
 
+import "math/big"
+
+// ERC20Metadata amounts are decimal strings to keep arbitrary precision in JSON
 type ERC20Metadata struct {
 	Name        string `json:"name"`
 	Symbol      string `json:"symbol"`
 	Owner       string `json:"owner"`
-	TotalSupply uint64 `json:"totalSupply"`
+	Decimals    uint8  `json:"decimals"`
+	TotalSupply string `json:"totalSupply"`
+	Cap         string `json:"cap,omitempty"`
+}
+
+// CapInfo is the response of cap query
+type CapInfo struct {
+	Cap         string `json:"cap"`
+	TotalSupply string `json:"totalSupply"`
+	Remaining   string `json:"remaining"`
 }
 
-func NewERC20Metadata(name, symbol, owner string, totalSupply uint) *ERC20Metadata {
+func NewERC20Metadata(name, symbol, owner string, decimals uint8, totalSupply *big.Int) *ERC20Metadata {
 
 	return &ERC20Metadata{
 		Name:        name,
 		Symbol:      symbol,
 		Owner:       owner,
-		TotalSupply: uint64(totalSupply),
+		Decimals:    decimals,
+		TotalSupply: totalSupply.String(),
+	}
+}
+
+func (erc20 *ERC20Metadata) GetTotalSupply() *big.Int {
+	totalSupply, ok := new(big.Int).SetString(erc20.TotalSupply, 10)
+	if !ok {
+		return new(big.Int)
 	}
+	return totalSupply
 }
 
-func (erc20 *ERC20Metadata) GetTotalSupply() *uint64 {
-	return &erc20.TotalSupply
+func (erc20 *ERC20Metadata) SetTotalSupply(totalSupply *big.Int) {
+	erc20.TotalSupply = totalSupply.String()
+}
+
+// GetCap returns cap, nil if the token is not capped
+// a cap which does not parse is an error, so a corrupt cap never reads as uncapped
+func (erc20 *ERC20Metadata) GetCap() (*big.Int, error) {
+	if erc20.Cap == "" {
+		return nil, nil
+	}
+
+	capAmount, ok := new(big.Int).SetString(erc20.Cap, 10)
+	if !ok {
+		return nil, NewCustomError(ConvertErrorType, "cap", "invalid cap "+erc20.Cap)
+	}
+	return capAmount, nil
+}
+
+func (erc20 *ERC20Metadata) SetCap(capAmount *big.Int) {
+	erc20.Cap = capAmount.String()
 }
 
 func (erc20 *ERC20Metadata) GetName() *string {
@@ -32,3 +71,7 @@ func (erc20 *ERC20Metadata) GetSymbol() *string {
 func (erc20 *ERC20Metadata) GetOwner() *string {
 	return &erc20.Owner
 }
+
+func (erc20 *ERC20Metadata) GetDecimals() *uint8 {
+	return &erc20.Decimals
+}
@@ -1,17 +1,21 @@
 package model
 
+import "math/big"
+
 type Approval struct {
+	Token     string `json:"token"`
 	Spender   string `json:"spender"`
 	Owner     string `json:"Owner"`
-	Allowance int    `json:"allowance"`
+	Allowance string `json:"allowance"`
 }
 
-func NewApproval(spender, owner string, allowance int) *Approval {
+func NewApproval(token, spender, owner string, allowance *big.Int) *Approval {
 
 	return &Approval{
+		Token:     token,
 		Spender:   spender,
 		Owner:     owner,
-		Allowance: allowance,
+		Allowance: allowance.String(),
 	}
 
 }
@@ -0,0 +1,22 @@
+package model
+
+// UnparseableBalance is a balance or delta entry whose value is not an integer
+type UnparseableBalance struct {
+	Key     string `json:"key"`
+	Address string `json:"address"`
+	Value   string `json:"value"`
+}
+
+// SupplyAudit is the report of comparing the sum of balances with the total supply
+// difference is balance sum - total supply
+type SupplyAudit struct {
+	Token          string               `json:"token"`
+	TotalSupply    string               `json:"totalSupply"`
+	BalanceSum     string               `json:"balanceSum"`
+	Difference     string               `json:"difference"`
+	Consistent     bool                 `json:"consistent"`
+	HolderCount    int                  `json:"holderCount"`
+	LargestHolders []Holder             `json:"largestHolders"`
+	Discrepancies  []string             `json:"discrepancies"`
+	Unparseable    []UnparseableBalance `json:"unparseable"`
+}
@@ -0,0 +1,30 @@
+package model
+
+import "math/big"
+
+// TransferLeg is a recipient and amount of a batch
+// fee is set in the event when the leg paid fee
+type TransferLeg struct {
+	Recipient string `json:"recipient"`
+	Amount    string `json:"amount"`
+	Fee       string `json:"fee,omitempty"`
+}
+
+// BatchTransferEvent is the Event of batch transfer & airdrop, one event describes every leg
+type BatchTransferEvent struct {
+	Token    string        `json:"token"`
+	Sender   string        `json:"sender"`
+	Total    string        `json:"total"`
+	Treasury string        `json:"treasury,omitempty"`
+	Legs     []TransferLeg `json:"legs"`
+}
+
+func NewBatchTransferEvent(token, sender string, total *big.Int, treasury string, legs []TransferLeg) *BatchTransferEvent {
+	return &BatchTransferEvent{
+		Token:    token,
+		Sender:   sender,
+		Total:    total.String(),
+		Treasury: treasury,
+		Legs:     legs,
+	}
+}
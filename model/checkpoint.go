@@ -0,0 +1,24 @@
+package model
+
+import "math/big"
+
+// Checkpoint is the value recorded at key, key is a snapshot id or unix seconds
+type Checkpoint struct {
+	Key   int64  `json:"key"`
+	Value string `json:"value"`
+}
+
+func NewCheckpoint(key int64, value *big.Int) *Checkpoint {
+	return &Checkpoint{
+		Key:   key,
+		Value: value.String(),
+	}
+}
+
+func (checkpoint *Checkpoint) GetValue() *big.Int {
+	value, ok := new(big.Int).SetString(checkpoint.Value, 10)
+	if !ok {
+		return new(big.Int)
+	}
+	return value
+}
@@ -0,0 +1,186 @@
+package model
+
+import "math/big"
+
+// DividendNoticePeriod is the least time (seconds) holders get to claim once a deadline is set on a distribution without one
+const DividendNoticePeriod = 30 * 24 * 60 * 60
+
+// Distribution is a payout to holders of token pro rata to their balance at snapshot
+// the payout token is a token of this chaincode deposited in escrow
+// after deadline (unix seconds, 0 for none) holders cannot claim and payer reclaims every unclaimed share, which closes it
+// claims are saved per holder, so the distribution only changes when payer reclaims and holders claim without conflicts
+// reclaimed is the amount paid back to payer
+type Distribution struct {
+	ID          string `json:"id"`
+	Token       string `json:"token"`
+	SnapshotID  int64  `json:"snapshotId"`
+	PayoutToken string `json:"payoutToken"`
+	Payer       string `json:"payer"`
+	Amount      string `json:"amount"`
+	TotalSupply string `json:"totalSupply"`
+	Reclaimed   string `json:"reclaimed"`
+	Deadline    int64  `json:"deadline"`
+	Closed      bool   `json:"closed"`
+}
+
+// DividendClaim is the share paid for the balance of an account at snapshot
+// the share of an account nobody can claim for is reclaimed by payer
+type DividendClaim struct {
+	Share     string `json:"share"`
+	Balance   string `json:"balance"`
+	Reclaimed bool   `json:"reclaimed"`
+}
+
+// DistributionInfo is the response of distributionInfo query
+// claimed supply includes the balances whose share was reclaimed
+type DistributionInfo struct {
+	Distribution
+	Claimed       string `json:"claimed"`
+	ClaimedSupply string `json:"claimedSupply"`
+	Unclaimed     string `json:"unclaimed"`
+	Dust          string `json:"dust"`
+}
+
+func NewDistribution(id, token string, snapshotID int64, payoutToken, payer string, amount, totalSupply *big.Int, deadline int64) *Distribution {
+	return &Distribution{
+		ID:          id,
+		Token:       token,
+		SnapshotID:  snapshotID,
+		PayoutToken: payoutToken,
+		Payer:       payer,
+		Amount:      amount.String(),
+		TotalSupply: totalSupply.String(),
+		Reclaimed:   "0",
+		Deadline:    deadline,
+	}
+}
+
+func NewDividendClaim(share, balance *big.Int, reclaimed bool) *DividendClaim {
+	return &DividendClaim{
+		Share:     share.String(),
+		Balance:   balance.String(),
+		Reclaimed: reclaimed,
+	}
+}
+
+func (distribution *Distribution) GetAmount() (*big.Int, error) {
+	amount, ok := new(big.Int).SetString(distribution.Amount, 10)
+	if !ok {
+		return nil, NewCustomError(ConvertErrorType, "amount", "invalid amount "+distribution.Amount)
+	}
+	return amount, nil
+}
+
+func (distribution *Distribution) GetTotalSupply() (*big.Int, error) {
+	totalSupply, ok := new(big.Int).SetString(distribution.TotalSupply, 10)
+	if !ok || totalSupply.Sign() <= 0 {
+		return nil, NewCustomError(ConvertErrorType, "totalSupply", "invalid total supply "+distribution.TotalSupply)
+	}
+	return totalSupply, nil
+}
+
+func (distribution *Distribution) GetReclaimed() (*big.Int, error) {
+	reclaimed, ok := new(big.Int).SetString(distribution.Reclaimed, 10)
+	if !ok {
+		return nil, NewCustomError(ConvertErrorType, "reclaimed", "invalid reclaimed "+distribution.Reclaimed)
+	}
+	return reclaimed, nil
+}
+
+func (claim *DividendClaim) GetShare() (*big.Int, error) {
+	share, ok := new(big.Int).SetString(claim.Share, 10)
+	if !ok {
+		return nil, NewCustomError(ConvertErrorType, "share", "invalid share "+claim.Share)
+	}
+	return share, nil
+}
+
+func (claim *DividendClaim) GetBalance() (*big.Int, error) {
+	balance, ok := new(big.Int).SetString(claim.Balance, 10)
+	if !ok {
+		return nil, NewCustomError(ConvertErrorType, "balance", "invalid balance "+claim.Balance)
+	}
+	return balance, nil
+}
+
+// IsExpired returns true if the deadline has passed at now
+func (distribution *Distribution) IsExpired(now int64) bool {
+	return distribution.Deadline > 0 && now >= distribution.Deadline
+}
+
+// ShareOf returns amount * balance / total supply rounded down
+func (distribution *Distribution) ShareOf(balance *big.Int) (*big.Int, error) {
+	amount, err := distribution.GetAmount()
+	if err != nil {
+		return nil, err
+	}
+
+	totalSupply, err := distribution.GetTotalSupply()
+	if err != nil {
+		return nil, err
+	}
+
+	share := new(big.Int).Mul(amount, balance)
+	return share.Quo(share, totalSupply), nil
+}
+
+// SumClaims returns the shares paid to holders and the balances whose share is paid or reclaimed
+func SumClaims(claims []*DividendClaim) (*big.Int, *big.Int, error) {
+
+	claimed, claimedSupply := new(big.Int), new(big.Int)
+	for _, claim := range claims {
+		if !claim.Reclaimed {
+			share, err := claim.GetShare()
+			if err != nil {
+				return nil, nil, err
+			}
+			claimed.Add(claimed, share)
+		}
+
+		balance, err := claim.GetBalance()
+		if err != nil {
+			return nil, nil, err
+		}
+		claimedSupply.Add(claimedSupply, balance)
+	}
+
+	return claimed, claimedSupply, nil
+}
+
+// Unclaimed returns the share of the balances which are not claimed yet, nothing once the distribution is closed
+func (distribution *Distribution) Unclaimed(claimedSupply *big.Int) (*big.Int, error) {
+
+	if distribution.Closed {
+		return new(big.Int), nil
+	}
+
+	totalSupply, err := distribution.GetTotalSupply()
+	if err != nil {
+		return nil, err
+	}
+
+	return distribution.ShareOf(new(big.Int).Sub(totalSupply, claimedSupply))
+}
+
+// Dust returns the amount in escrow which no holder can claim and payer did not reclaim yet
+// it is the rounding dust, plus the shares of reclaimed accounts until payer reclaims them
+func (distribution *Distribution) Dust(claimed, claimedSupply *big.Int) (*big.Int, error) {
+	amount, err := distribution.GetAmount()
+	if err != nil {
+		return nil, err
+	}
+
+	reclaimed, err := distribution.GetReclaimed()
+	if err != nil {
+		return nil, err
+	}
+
+	unclaimed, err := distribution.Unclaimed(claimedSupply)
+	if err != nil {
+		return nil, err
+	}
+
+	dust := new(big.Int).Sub(amount, claimed)
+	dust.Sub(dust, reclaimed)
+	return dust.Sub(dust, unclaimed), nil
+}
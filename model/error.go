@@ -3,12 +3,22 @@ package model
 import "fmt"
 
 const (
-	MarshalErrorType   = "Marshal"
-	ConvertErrorType   = "Convert"
-	PutStateErrorType  = "PutState"
-	GetStateErrorType  = "GetState"
-	UnmarshalErrorType = "Unmarshal"
-	SetEventErrorType  = "Event"
+	MarshalErrorType      = "Marshal"
+	ConvertErrorType      = "Convert"
+	PutStateErrorType     = "PutState"
+	GetStateErrorType     = "GetState"
+	UnmarshalErrorType    = "Unmarshal"
+	SetEventErrorType     = "Event"
+	DelStateErrorType     = "DelState"
+	CompositeKeyErrorType = "CreateCompositeKey"
+	IdentifyErrorType     = "Identify"
+	AuthenticateErrorType = "Authenticate"
+	AuthorizeErrorType    = "Authorize"
+	OverflowErrorType     = "Add"
+	UnderflowErrorType    = "Subtract"
+	PausedErrorType       = "Execute"
+	FrozenErrorType       = "Transact"
+	VerifyErrorType       = "Verify"
 )
 
 type CustomError struct {
@@ -0,0 +1,26 @@
+package model
+
+import "encoding/json"
+
+// EventEnvelopeVersion is the version of the envelope format emitted by the chaincode
+const EventEnvelopeVersion = 1
+
+// EventRecord is one event collected during a transaction
+type EventRecord struct {
+	Name    string          `json:"name"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+// EventEnvelope is the single Event emitted per transaction
+// events are kept in the order they were set
+type EventEnvelope struct {
+	Version int           `json:"version"`
+	Events  []EventRecord `json:"events"`
+}
+
+func NewEventEnvelope(events []EventRecord) *EventEnvelope {
+	return &EventEnvelope{
+		Version: EventEnvelopeVersion,
+		Events:  events,
+	}
+}
@@ -0,0 +1,77 @@
+package model
+
+import "math/big"
+
+// FeeConfig is the transfer fee of token paid to treasury
+// fee is amount * basis points / 10000, at least minimum and at most maximum(0 is no maximum)
+type FeeConfig struct {
+	Token       string `json:"token"`
+	BasisPoints int64  `json:"basisPoints"`
+	Minimum     string `json:"minimum"`
+	Maximum     string `json:"maximum"`
+	Treasury    string `json:"treasury"`
+}
+
+// FeeQuote is the response of quoteFee query
+type FeeQuote struct {
+	Amount   string `json:"amount"`
+	Fee      string `json:"fee"`
+	Received string `json:"received"`
+	Treasury string `json:"treasury,omitempty"`
+}
+
+func NewFeeConfig(token string, basisPoints int64, minimum, maximum *big.Int, treasury string) *FeeConfig {
+	return &FeeConfig{
+		Token:       token,
+		BasisPoints: basisPoints,
+		Minimum:     minimum.String(),
+		Maximum:     maximum.String(),
+		Treasury:    treasury,
+	}
+}
+
+func (config *FeeConfig) GetMinimum() (*big.Int, error) {
+	minimum, ok := new(big.Int).SetString(config.Minimum, 10)
+	if !ok {
+		return nil, NewCustomError(ConvertErrorType, "minimum fee", "invalid minimum fee "+config.Minimum)
+	}
+	return minimum, nil
+}
+
+func (config *FeeConfig) GetMaximum() (*big.Int, error) {
+	maximum, ok := new(big.Int).SetString(config.Maximum, 10)
+	if !ok {
+		return nil, NewCustomError(ConvertErrorType, "maximum fee", "invalid maximum fee "+config.Maximum)
+	}
+	return maximum, nil
+}
+
+// FeeOf returns the fee of amount, the fee is never more than amount
+func (config *FeeConfig) FeeOf(amount *big.Int) (*big.Int, error) {
+	minimum, err := config.GetMinimum()
+	if err != nil {
+		return nil, err
+	}
+
+	maximum, err := config.GetMaximum()
+	if err != nil {
+		return nil, err
+	}
+
+	fee := new(big.Int).Mul(amount, big.NewInt(config.BasisPoints))
+	fee.Quo(fee, big.NewInt(BasisPoints))
+
+	if fee.Cmp(minimum) < 0 {
+		fee = minimum
+	}
+
+	if maximum.Sign() > 0 && fee.Cmp(maximum) > 0 {
+		fee = maximum
+	}
+
+	if fee.Cmp(amount) > 0 {
+		fee = new(big.Int).Set(amount)
+	}
+
+	return fee, nil
+}
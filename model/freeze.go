@@ -0,0 +1,18 @@
+package model
+
+// FreezeRecord is saved for every frozen account
+type FreezeRecord struct {
+	Address   string `json:"address"`
+	FrozenBy  string `json:"frozenBy"`
+	TxID      string `json:"txId"`
+	Timestamp int64  `json:"timestamp"`
+}
+
+func NewFreezeRecord(address, frozenBy, txID string, timestamp int64) *FreezeRecord {
+	return &FreezeRecord{
+		Address:   address,
+		FrozenBy:  frozenBy,
+		TxID:      txID,
+		Timestamp: timestamp,
+	}
+}
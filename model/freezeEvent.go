@@ -0,0 +1,15 @@
+package model
+
+// FreezeEvent is the Event of freezeAccount & unfreezeAccount
+// account is the admin who froze or unfroze address
+type FreezeEvent struct {
+	Address string `json:"address"`
+	Account string `json:"account"`
+}
+
+func NewFreezeEvent(address, account string) *FreezeEvent {
+	return &FreezeEvent{
+		Address: address,
+		Account: account,
+	}
+}
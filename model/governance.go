@@ -0,0 +1,134 @@
+package model
+
+import "math/big"
+
+const (
+	ProposalActive    = "ACTIVE"
+	ProposalSucceeded = "SUCCEEDED"
+	ProposalDefeated  = "DEFEATED"
+	ProposalExecuted  = "EXECUTED"
+)
+
+const (
+	VoteFor     = "for"
+	VoteAgainst = "against"
+	VoteAbstain = "abstain"
+)
+
+// BasisPoints is the denominator of quorum and threshold
+const BasisPoints = 10000
+
+// GovernanceConfig is the voting rule of token
+// quorum is the share of total supply at proposal which must vote, threshold is the share of for votes
+// among for and against votes which must be exceeded, proposal threshold is the share of total supply
+// the proposer must hold, all are in basis points
+type GovernanceConfig struct {
+	Token             string `json:"token"`
+	Quorum            int64  `json:"quorum"`
+	Threshold         int64  `json:"threshold"`
+	ProposalThreshold int64  `json:"proposalThreshold"`
+}
+
+func NewGovernanceConfig(token string, quorum, threshold, proposalThreshold int64) *GovernanceConfig {
+	return &GovernanceConfig{
+		Token:             token,
+		Quorum:            quorum,
+		Threshold:         threshold,
+		ProposalThreshold: proposalThreshold,
+	}
+}
+
+// CanPropose returns true if balance reaches the proposal threshold of total supply
+func (config *GovernanceConfig) CanPropose(balance, totalSupply *big.Int) bool {
+	// balance * 10000 >= proposal threshold * total supply
+	required := new(big.Int).Mul(totalSupply, big.NewInt(config.ProposalThreshold))
+	return new(big.Int).Mul(balance, big.NewInt(BasisPoints)).Cmp(required) >= 0
+}
+
+// ProposalAction is an admin function run by an executed proposal
+type ProposalAction struct {
+	Function string   `json:"function"`
+	Args     []string `json:"args"`
+}
+
+// Proposal is voted with balances at snapshot until end(unix seconds)
+type Proposal struct {
+	ID           string           `json:"id"`
+	Token        string           `json:"token"`
+	Proposer     string           `json:"proposer"`
+	Description  string           `json:"description"`
+	Actions      []ProposalAction `json:"actions"`
+	SnapshotID   int64            `json:"snapshotId"`
+	Start        int64            `json:"start"`
+	End          int64            `json:"end"`
+	Quorum       int64            `json:"quorum"`
+	Threshold    int64            `json:"threshold"`
+	State        string           `json:"state"`
+	ForVotes     string           `json:"forVotes"`
+	AgainstVotes string           `json:"againstVotes"`
+	AbstainVotes string           `json:"abstainVotes"`
+}
+
+func NewProposal(id, token, proposer, description string, actions []ProposalAction, snapshotID, start, end int64, config *GovernanceConfig) *Proposal {
+	return &Proposal{
+		ID:           id,
+		Token:        token,
+		Proposer:     proposer,
+		Description:  description,
+		Actions:      actions,
+		SnapshotID:   snapshotID,
+		Start:        start,
+		End:          end,
+		Quorum:       config.Quorum,
+		Threshold:    config.Threshold,
+		State:        ProposalActive,
+		ForVotes:     "0",
+		AgainstVotes: "0",
+		AbstainVotes: "0",
+	}
+}
+
+// Tally saves the votes and decides the state of proposal
+func (proposal *Proposal) Tally(forVotes, againstVotes, abstainVotes, totalSupply *big.Int) {
+	proposal.ForVotes = forVotes.String()
+	proposal.AgainstVotes = againstVotes.String()
+	proposal.AbstainVotes = abstainVotes.String()
+
+	// votes * 10000 >= quorum * total supply
+	votes := new(big.Int).Add(forVotes, againstVotes)
+	votes.Add(votes, abstainVotes)
+	quorumReached := votes.Mul(votes, big.NewInt(BasisPoints)).Cmp(new(big.Int).Mul(totalSupply, big.NewInt(proposal.Quorum))) >= 0
+
+	// for * 10000 > threshold * (for + against)
+	decided := new(big.Int).Add(forVotes, againstVotes)
+	thresholdExceeded := new(big.Int).Mul(forVotes, big.NewInt(BasisPoints)).Cmp(decided.Mul(decided, big.NewInt(proposal.Threshold))) > 0
+
+	proposal.State = ProposalDefeated
+	if quorumReached && thresholdExceeded {
+		proposal.State = ProposalSucceeded
+	}
+}
+
+// Vote is the vote of voter with the weight at the proposal snapshot
+type Vote struct {
+	Voter   string `json:"voter"`
+	Support string `json:"support"`
+	Weight  string `json:"weight"`
+}
+
+func NewVote(voter, support string, weight *big.Int) *Vote {
+	return &Vote{
+		Voter:   voter,
+		Support: support,
+		Weight:  weight.String(),
+	}
+}
+
+// GetWeight returns the weight of vote, a weight which does not parse is an error so the vote is never dropped
+func (vote *Vote) GetWeight() (*big.Int, error) {
+	weight, ok := new(big.Int).SetString(vote.Weight, 10)
+	if !ok {
+		return nil, NewCustomError(ConvertErrorType, "weight", "invalid weight "+vote.Weight)
+	}
+	return weight, nil
+}
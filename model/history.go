@@ -0,0 +1,24 @@
+package model
+
+// HistoryEntry is one modification of a ledger key
+type HistoryEntry struct {
+	TxID      string `json:"txId"`
+	Timestamp int64  `json:"timestamp"`
+	Value     string `json:"value"`
+	IsDelete  bool   `json:"isDelete"`
+}
+
+// HistoryPage is a page of key history, bookmark is empty on the last page
+type HistoryPage struct {
+	Entries  []HistoryEntry `json:"entries"`
+	Bookmark string         `json:"bookmark"`
+}
+
+func NewHistoryEntry(txID string, timestamp int64, value string, isDelete bool) *HistoryEntry {
+	return &HistoryEntry{
+		TxID:      txID,
+		Timestamp: timestamp,
+		Value:     value,
+		IsDelete:  isDelete,
+	}
+}
@@ -0,0 +1,44 @@
+package model
+
+import "math/big"
+
+const (
+	HTLCOpen     = "OPEN"
+	HTLCClaimed  = "CLAIMED"
+	HTLCRefunded = "REFUNDED"
+)
+
+// HTLC is the hash time-locked escrow of a swap
+// hashlock is hex(sha256(preimage)), timelock is unix seconds
+type HTLC struct {
+	ID        string `json:"id"`
+	Token     string `json:"token"`
+	Sender    string `json:"sender"`
+	Recipient string `json:"recipient"`
+	Amount    string `json:"amount"`
+	Hashlock  string `json:"hashlock"`
+	Timelock  int64  `json:"timelock"`
+	Preimage  string `json:"preimage,omitempty"`
+	Status    string `json:"status"`
+}
+
+func NewHTLC(id, token, sender, recipient string, amount *big.Int, hashlock string, timelock int64) *HTLC {
+	return &HTLC{
+		ID:        id,
+		Token:     token,
+		Sender:    sender,
+		Recipient: recipient,
+		Amount:    amount.String(),
+		Hashlock:  hashlock,
+		Timelock:  timelock,
+		Status:    HTLCOpen,
+	}
+}
+
+func (htlc *HTLC) GetAmount() *big.Int {
+	amount, ok := new(big.Int).SetString(htlc.Amount, 10)
+	if !ok {
+		return new(big.Int)
+	}
+	return amount
+}
@@ -0,0 +1,33 @@
+package model
+
+// PageMetadata is returned with every paginated query
+// bookmark is passed to the next query to get the next page
+type PageMetadata struct {
+	FetchedRecordsCount int32  `json:"fetchedRecordsCount"`
+	Bookmark            string `json:"bookmark"`
+}
+
+// ApprovalPage is a page of approvalList
+type ApprovalPage struct {
+	Approvals []Approval   `json:"approvals"`
+	Metadata  PageMetadata `json:"metadata"`
+}
+
+// Holder is an address with non-zero balance
+type Holder struct {
+	Address string `json:"address"`
+	Balance string `json:"balance"`
+}
+
+// HolderPage is a page of holders
+type HolderPage struct {
+	Holders  []Holder     `json:"holders"`
+	Metadata PageMetadata `json:"metadata"`
+}
+
+func NewPageMetadata(fetchedRecordsCount int32, bookmark string) *PageMetadata {
+	return &PageMetadata{
+		FetchedRecordsCount: fetchedRecordsCount,
+		Bookmark:            bookmark,
+	}
+}
@@ -0,0 +1,12 @@
+package model
+
+// PauseEvent is the Event of pause & unpause
+type PauseEvent struct {
+	Account string `json:"account"`
+}
+
+func NewPauseEvent(account string) *PauseEvent {
+	return &PauseEvent{
+		Account: account,
+	}
+}
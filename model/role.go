@@ -0,0 +1,34 @@
+package model
+
+const (
+	AdminRole  = "ADMIN"
+	MinterRole = "MINTER"
+	BurnerRole = "BURNER"
+	PauserRole = "PAUSER"
+)
+
+// Roles is the list of roles which can be granted
+var Roles = []string{AdminRole, MinterRole, BurnerRole, PauserRole}
+
+// IsRole returns true if role is one of the known roles
+func IsRole(role string) bool {
+	for _, r := range Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// TokenRoles are granted per token, the other roles are chaincode wide
+var TokenRoles = []string{MinterRole, BurnerRole}
+
+// IsTokenRole returns true if role is granted per token
+func IsTokenRole(role string) bool {
+	for _, r := range TokenRoles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,11 @@
+package model
+
+// SignedRequest is the request a key holder signs to be relayed by executeSigned
+// args are the params of function as the signer would submit them
+type SignedRequest struct {
+	Signer   string   `json:"signer"`
+	Function string   `json:"function"`
+	Args     []string `json:"args"`
+	Nonce    string   `json:"nonce"`
+	Expiry   string   `json:"expiry"`
+}
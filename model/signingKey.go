@@ -0,0 +1,16 @@
+package model
+
+// SigningKey binds a public key to an account address
+type SigningKey struct {
+	Address   string `json:"address"`
+	Curve     string `json:"curve"`
+	PublicKey string `json:"publicKey"`
+}
+
+func NewSigningKey(address, curve, publicKey string) *SigningKey {
+	return &SigningKey{
+		Address:   address,
+		Curve:     curve,
+		PublicKey: publicKey,
+	}
+}
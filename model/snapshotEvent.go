@@ -0,0 +1,14 @@
+package model
+
+// SnapshotEvent is the Event of snapshot
+type SnapshotEvent struct {
+	Token string `json:"token"`
+	ID    int64  `json:"id"`
+}
+
+func NewSnapshotEvent(token string, id int64) *SnapshotEvent {
+	return &SnapshotEvent{
+		Token: token,
+		ID:    id,
+	}
+}
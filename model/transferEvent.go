@@ -1,16 +1,30 @@
 package model
 
+import "math/big"
+
 // TransferEvent is the Event
+// when fee is charged, recipient receives amount - fee and treasury receives fee
 type TransferEvent struct {
+	Token     string `json:"token"`
 	Sender    string `json:"sender"`
 	Recipient string `json:"recipient"`
-	Amount    int    `json:"amount"`
+	Amount    string `json:"amount"`
+	Fee       string `json:"fee,omitempty"`
+	Treasury  string `json:"treasury,omitempty"`
 }
 
-func NewTransferEvent(sender, recipient string, amount int) *TransferEvent {
+func NewTransferEvent(token, sender, recipient string, amount *big.Int) *TransferEvent {
 	return &TransferEvent{
+		Token:     token,
 		Sender:    sender,
 		Recipient: recipient,
-		Amount:    amount,
+		Amount:    amount.String(),
 	}
 }
+
+func NewTransferFeeEvent(token, sender, recipient string, amount, fee *big.Int, treasury string) *TransferEvent {
+	transferEvent := NewTransferEvent(token, sender, recipient, amount)
+	transferEvent.Fee = fee.String()
+	transferEvent.Treasury = treasury
+	return transferEvent
+}
@@ -0,0 +1,124 @@
+package model
+
+import "math/big"
+
+// Vesting is the schedule of tokens locked for beneficiary
+// start is unix seconds, cliff and duration are seconds from start
+type Vesting struct {
+	ID          string `json:"id"`
+	Token       string `json:"token"`
+	Grantor     string `json:"grantor"`
+	Beneficiary string `json:"beneficiary"`
+	Total       string `json:"total"`
+	Released    string `json:"released"`
+	Start       int64  `json:"start"`
+	Cliff       int64  `json:"cliff"`
+	Duration    int64  `json:"duration"`
+	Revocable   bool   `json:"revocable"`
+	Revoked     bool   `json:"revoked"`
+	Refunded    string `json:"refunded,omitempty"`
+}
+
+// VestingInfo is the response of vestingInfo query
+type VestingInfo struct {
+	Vesting
+	Vested     string `json:"vested"`
+	Releasable string `json:"releasable"`
+}
+
+func NewVesting(id, token, grantor, beneficiary string, total *big.Int, start, cliff, duration int64, revocable bool) *Vesting {
+	return &Vesting{
+		ID:          id,
+		Token:       token,
+		Grantor:     grantor,
+		Beneficiary: beneficiary,
+		Total:       total.String(),
+		Released:    "0",
+		Start:       start,
+		Cliff:       cliff,
+		Duration:    duration,
+		Revocable:   revocable,
+	}
+}
+
+func (vesting *Vesting) GetTotal() (*big.Int, error) {
+	total, ok := new(big.Int).SetString(vesting.Total, 10)
+	if !ok {
+		return nil, NewCustomError(ConvertErrorType, "total", "invalid total "+vesting.Total)
+	}
+	return total, nil
+}
+
+func (vesting *Vesting) GetReleased() (*big.Int, error) {
+	released, ok := new(big.Int).SetString(vesting.Released, 10)
+	if !ok {
+		return nil, NewCustomError(ConvertErrorType, "released", "invalid released "+vesting.Released)
+	}
+	return released, nil
+}
+
+func (vesting *Vesting) SetReleased(released *big.Int) {
+	vesting.Released = released.String()
+}
+
+// GetRefunded returns the unvested amount refunded on revoke, 0 if the schedule is not revoked
+func (vesting *Vesting) GetRefunded() (*big.Int, error) {
+	if vesting.Refunded == "" {
+		return new(big.Int), nil
+	}
+
+	refunded, ok := new(big.Int).SetString(vesting.Refunded, 10)
+	if !ok {
+		return nil, NewCustomError(ConvertErrorType, "refunded", "invalid refunded "+vesting.Refunded)
+	}
+	return refunded, nil
+}
+
+func (vesting *Vesting) SetRefunded(refunded *big.Int) {
+	vesting.Refunded = refunded.String()
+}
+
+// VestedAmount returns the amount vested at now
+// nothing vests before the cliff, then total vests linearly until start + duration
+// a revoked schedule keeps what was vested when it was revoked
+func (vesting *Vesting) VestedAmount(now int64) (*big.Int, error) {
+	total, err := vesting.GetTotal()
+	if err != nil {
+		return nil, err
+	}
+
+	if vesting.Revoked {
+		refunded, err := vesting.GetRefunded()
+		if err != nil {
+			return nil, err
+		}
+		return total.Sub(total, refunded), nil
+	}
+
+	elapsed := now - vesting.Start
+	if elapsed < vesting.Cliff {
+		return new(big.Int), nil
+	}
+
+	if elapsed >= vesting.Duration {
+		return total, nil
+	}
+
+	vested := total.Mul(total, big.NewInt(elapsed))
+	return vested.Quo(vested, big.NewInt(vesting.Duration)), nil
+}
+
+// ReleasableAmount returns the vested amount which is not released yet
+func (vesting *Vesting) ReleasableAmount(now int64) (*big.Int, error) {
+	vested, err := vesting.VestedAmount(now)
+	if err != nil {
+		return nil, err
+	}
+
+	released, err := vesting.GetReleased()
+	if err != nil {
+		return nil, err
+	}
+
+	return vested.Sub(vested, released), nil
+}
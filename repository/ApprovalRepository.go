@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"hyperledger_dapp/model"
+	"math/big"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+const ApprovalPrefix = "approval"
+
+// CreateApprovalKey makes composite key for allowance - approval/{tokenName}/{owner}/{spender}
+func CreateApprovalKey(stub shim.ChaincodeStubInterface, tokenName, owner, spender string) (string, error) {
+
+	approvalKey, err := stub.CreateCompositeKey(ApprovalPrefix, []string{tokenName, owner, spender})
+	if err != nil {
+		return "", model.NewCustomError(model.CompositeKeyErrorType, ApprovalPrefix, err.Error())
+	}
+
+	return approvalKey, nil
+}
+
+func SaveAllowance(stub shim.ChaincodeStubInterface, tokenName, owner, spender string, allowance *big.Int) error {
+
+	approvalKey, err := CreateApprovalKey(stub, tokenName, owner, spender)
+	if err != nil {
+		return err
+	}
+
+	// save allowance amount
+	err = stub.PutState(approvalKey, []byte(allowance.String()))
+	if err != nil {
+		return model.NewCustomError(model.PutStateErrorType, ApprovalPrefix, err.Error())
+	}
+
+	return nil
+}
+
+func GetAllowance(stub shim.ChaincodeStubInterface, tokenName, owner, spender string) (*big.Int, error) {
+
+	approvalKey, err := CreateApprovalKey(stub, tokenName, owner, spender)
+	if err != nil {
+		return nil, err
+	}
+
+	allowanceBytes, err := stub.GetState(approvalKey)
+	if err != nil {
+		return nil, model.NewCustomError(model.GetStateErrorType, ApprovalPrefix, err.Error())
+	}
+
+	// no approval means zero allowance
+	if allowanceBytes == nil {
+		allowanceBytes = []byte("0")
+	}
+
+	allowance, ok := new(big.Int).SetString(string(allowanceBytes), 10)
+	if !ok {
+		return nil, model.NewCustomError(model.ConvertErrorType, "allowance", "invalid allowance "+string(allowanceBytes))
+	}
+
+	return allowance, nil
+}
@@ -3,22 +3,49 @@ package repository
 import (
 	"encoding/json"
 	"hyperledger_dapp/model"
-	"strconv"
+	"math/big"
 
 	"github.com/hyperledger/fabric-chaincode-go/shim"
 )
 
-func SaveERC20Metadata(stub shim.ChaincodeStubInterface, tokenName, symbol, owner string, amountUint uint) error {
+const (
+	TokenPrefix   = "token"
+	BalancePrefix = "balance"
+)
+
+func SaveERC20Metadata(stub shim.ChaincodeStubInterface, metadata *model.ERC20Metadata) error {
+
+	tokenName := metadata.Name
+
+	// keep the total supply of the current snapshot
+	exists, err := ExistsERC20Metadata(stub, tokenName)
+	if err != nil {
+		return err
+	}
+
+	if exists {
+		err = updateSnapshot(stub, tokenName, newCheckpoints(SnapshotPrefix, "supply", tokenName), func() (*big.Int, error) {
+			return GetERC20TotalSupply(stub, tokenName)
+		})
+		if err != nil {
+			return err
+		}
+	}
 
 	// make metadata
-	metadata := model.NewERC20Metadata(tokenName, symbol, owner, uint(amountUint))
 	metadataBytes, err := json.Marshal(metadata)
 	if err != nil {
 		return model.NewCustomError(model.MarshalErrorType, "metadata", err.Error())
 	}
 
+	// create composite key for metadata - token/{tokenName}
+	tokenKey, err := stub.CreateCompositeKey(TokenPrefix, []string{tokenName})
+	if err != nil {
+		return model.NewCustomError(model.CompositeKeyErrorType, TokenPrefix, err.Error())
+	}
+
 	// save token meta data
-	err = stub.PutState(tokenName, metadataBytes)
+	err = stub.PutState(tokenKey, metadataBytes)
 	if err != nil {
 		return model.NewCustomError(model.PutStateErrorType, "putstate", err.Error())
 	}
@@ -26,37 +53,82 @@ func SaveERC20Metadata(stub shim.ChaincodeStubInterface, tokenName, symbol, owne
 	return nil
 }
 
-func GetERC20TotalSupply(stub shim.ChaincodeStubInterface, tokenName string) (*uint64, error) {
+func GetERC20TotalSupply(stub shim.ChaincodeStubInterface, tokenName string) (*big.Int, error) {
 
 	// get metadata
-	metadata := &model.ERC20Metadata{}
-
-	metadataBytes, err := stub.GetState(tokenName)
+	metadata, err := GetERC20Metadata(stub, tokenName)
 	if err != nil {
-		return nil, model.NewCustomError(model.GetStateErrorType, "balance", err.Error())
+		return nil, err
 	}
 
-	err = json.Unmarshal(metadataBytes, metadata)
+	return metadata.GetTotalSupply(), nil
+}
+
+// CreateBalanceKey makes composite key for balance - balance/{tokenName}/{owner}
+func CreateBalanceKey(stub shim.ChaincodeStubInterface, tokenName, owner string) (string, error) {
+
+	balanceKey, err := stub.CreateCompositeKey(BalancePrefix, []string{tokenName, owner})
 	if err != nil {
-		return nil, model.NewCustomError(model.UnmarshalErrorType, "unmarshal", err.Error())
+		return "", model.NewCustomError(model.CompositeKeyErrorType, BalancePrefix, err.Error())
 	}
 
-	return metadata.GetTotalSupply(), nil
+	return balanceKey, nil
 }
 
-func SaveBalance(stub shim.ChaincodeStubInterface, owner, balance string) error {
+// SaveBalance saves balance as the base value of owner, in delta mode it folds the deltas of owner into it
+// only an owner in delta mode has deltas, delta mode is disabled after its deltas are folded
+func SaveBalance(stub shim.ChaincodeStubInterface, tokenName, owner string, balance *big.Int) error {
+
+	// keep the balance of the current snapshot
+	err := updateSnapshot(stub, tokenName, newCheckpoints(SnapshotPrefix, "balance", tokenName, owner), func() (*big.Int, error) {
+		return GetBalance(stub, tokenName, owner, true)
+	})
+	if err != nil {
+		return err
+	}
+
+	deltaMode, err := IsDeltaMode(stub, tokenName, owner)
+	if err != nil {
+		return err
+	}
+
+	if deltaMode {
+		deltaKeys, _, err := getBalanceDeltas(stub, tokenName, owner)
+		if err != nil {
+			return err
+		}
+
+		for _, deltaKey := range deltaKeys {
+			err = stub.DelState(deltaKey)
+			if err != nil {
+				return model.NewCustomError(model.DelStateErrorType, BalanceDeltaPrefix, err.Error())
+			}
+		}
+	}
+
+	balanceKey, err := CreateBalanceKey(stub, tokenName, owner)
+	if err != nil {
+		return err
+	}
 
 	// save owner balance
-	err := stub.PutState(owner, []byte(balance))
+	err = stub.PutState(balanceKey, []byte(balance.String()))
 	if err != nil {
 		return model.NewCustomError(model.PutStateErrorType, "balance", err.Error())
 	}
 	return nil
 }
 
-func GetBalance(stub shim.ChaincodeStubInterface, owner string, isZero bool) (*int, error) {
+// GetBalance returns the base value of owner plus the deltas not folded yet
+func GetBalance(stub shim.ChaincodeStubInterface, tokenName, owner string, isZero bool) (*big.Int, error) {
+
+	balanceKey, err := CreateBalanceKey(stub, tokenName, owner)
+	if err != nil {
+		return nil, err
+	}
+
 	// get caller amount
-	AmountBytes, err := stub.GetState(owner)
+	AmountBytes, err := stub.GetState(balanceKey)
 	if err != nil {
 		return nil, model.NewCustomError(model.GetStateErrorType, "balance", err.Error())
 	}
@@ -65,23 +137,47 @@ func GetBalance(stub shim.ChaincodeStubInterface, owner string, isZero bool) (*i
 		AmountBytes = []byte("0")
 	}
 
-	amount, err := strconv.Atoi(string(AmountBytes))
+	amount, ok := new(big.Int).SetString(string(AmountBytes), 10)
+	if !ok {
+		return nil, model.NewCustomError(model.ConvertErrorType, "amount", "invalid balance "+string(AmountBytes))
+	}
+
+	// only an owner in delta mode has deltas, so the others skip the range query
+	deltaMode, err := IsDeltaMode(stub, tokenName, owner)
 	if err != nil {
-		return nil, model.NewCustomError(model.ConvertErrorType, "amount", err.Error())
+		return nil, err
 	}
 
-	return &amount, nil
+	if !deltaMode {
+		return amount, nil
+	}
+
+	_, deltaSum, err := getBalanceDeltas(stub, tokenName, owner)
+	if err != nil {
+		return nil, err
+	}
+
+	return amount.Add(amount, deltaSum), nil
 }
 
 func GetERC20Metadata(stub shim.ChaincodeStubInterface, tokenName string) (*model.ERC20Metadata, error) {
 
 	metadata := &model.ERC20Metadata{}
 
-	metadataBytes, err := stub.GetState(tokenName)
+	tokenKey, err := stub.CreateCompositeKey(TokenPrefix, []string{tokenName})
+	if err != nil {
+		return nil, model.NewCustomError(model.CompositeKeyErrorType, TokenPrefix, err.Error())
+	}
+
+	metadataBytes, err := stub.GetState(tokenKey)
 	if err != nil {
 		return nil, model.NewCustomError(model.GetStateErrorType, "balance", err.Error())
 	}
 
+	if metadataBytes == nil {
+		return nil, model.NewCustomError(model.GetStateErrorType, TokenPrefix, tokenName+" does not exist")
+	}
+
 	err = json.Unmarshal(metadataBytes, metadata)
 	if err != nil {
 		return nil, model.NewCustomError(model.UnmarshalErrorType, "unmarshal", err.Error())
@@ -89,3 +185,94 @@ func GetERC20Metadata(stub shim.ChaincodeStubInterface, tokenName string) (*mode
 
 	return metadata, nil
 }
+
+// ExistsERC20Metadata returns true if token is registered
+func ExistsERC20Metadata(stub shim.ChaincodeStubInterface, tokenName string) (bool, error) {
+
+	tokenKey, err := stub.CreateCompositeKey(TokenPrefix, []string{tokenName})
+	if err != nil {
+		return false, model.NewCustomError(model.CompositeKeyErrorType, TokenPrefix, err.Error())
+	}
+
+	metadataBytes, err := stub.GetState(tokenKey)
+	if err != nil {
+		return false, model.NewCustomError(model.GetStateErrorType, TokenPrefix, err.Error())
+	}
+
+	return metadataBytes != nil, nil
+}
+
+// ListERC20Metadata returns metadata of every registered token
+func ListERC20Metadata(stub shim.ChaincodeStubInterface) ([]model.ERC20Metadata, error) {
+
+	// get all token metadata (format is iterator)
+	tokenIterator, err := stub.GetStateByPartialCompositeKey(TokenPrefix, []string{})
+	if err != nil {
+		return nil, model.NewCustomError(model.GetStateErrorType, TokenPrefix, err.Error())
+	}
+	defer tokenIterator.Close()
+
+	metadataSlice := []model.ERC20Metadata{}
+	for tokenIterator.HasNext() {
+		tokenKV, err := tokenIterator.Next()
+		if err != nil {
+			return nil, model.NewCustomError(model.GetStateErrorType, TokenPrefix, err.Error())
+		}
+
+		metadata := model.ERC20Metadata{}
+		err = json.Unmarshal(tokenKV.GetValue(), &metadata)
+		if err != nil {
+			return nil, model.NewCustomError(model.UnmarshalErrorType, TokenPrefix, err.Error())
+		}
+		metadataSlice = append(metadataSlice, metadata)
+	}
+
+	return metadataSlice, nil
+}
+
+// ListBalances returns the balance of every owner of token including the deltas
+// entries whose value is not an integer are returned as unparseable and left out of the balances
+func ListBalances(stub shim.ChaincodeStubInterface, tokenName string) (map[string]*big.Int, []model.UnparseableBalance, error) {
+
+	balances := map[string]*big.Int{}
+	unparseable := []model.UnparseableBalance{}
+
+	for _, prefix := range []string{BalancePrefix, BalanceDeltaPrefix} {
+
+		// get all balance or delta of token (format is iterator)
+		balanceIterator, err := stub.GetStateByPartialCompositeKey(prefix, []string{tokenName})
+		if err != nil {
+			return nil, nil, model.NewCustomError(model.GetStateErrorType, prefix, err.Error())
+		}
+
+		for balanceIterator.HasNext() {
+			balanceKV, err := balanceIterator.Next()
+			if err != nil {
+				balanceIterator.Close()
+				return nil, nil, model.NewCustomError(model.GetStateErrorType, prefix, err.Error())
+			}
+
+			// get owner address - {prefix}/{tokenName}/{owner}[/{txId}]
+			_, attributes, err := stub.SplitCompositeKey(balanceKV.GetKey())
+			if err != nil || len(attributes) < 2 {
+				balanceIterator.Close()
+				return nil, nil, model.NewCustomError(model.CompositeKeyErrorType, prefix, "invalid key "+balanceKV.GetKey())
+			}
+			owner := attributes[1]
+
+			amount, ok := new(big.Int).SetString(string(balanceKV.GetValue()), 10)
+			if !ok {
+				unparseable = append(unparseable, model.UnparseableBalance{Key: balanceKV.GetKey(), Address: owner, Value: string(balanceKV.GetValue())})
+				continue
+			}
+
+			if _, ok = balances[owner]; !ok {
+				balances[owner] = new(big.Int)
+			}
+			balances[owner].Add(balances[owner], amount)
+		}
+		balanceIterator.Close()
+	}
+
+	return balances, unparseable, nil
+}
@@ -0,0 +1,193 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"hyperledger_dapp/model"
+	"strconv"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+// checkpoints is a list of checkpoints ordered by key
+// element i is saved at {objectType}/{attributes}/{i} and the length at {objectType}/{attributes}/length,
+// so lookups are binary searches over point reads instead of scans
+type checkpoints struct {
+	objectType string
+	attributes []string
+}
+
+func newCheckpoints(objectType string, attributes ...string) checkpoints {
+	return checkpoints{objectType: objectType, attributes: attributes}
+}
+
+func (list checkpoints) key(stub shim.ChaincodeStubInterface, last string) (string, error) {
+
+	attributes := append(append([]string{}, list.attributes...), last)
+	key, err := stub.CreateCompositeKey(list.objectType, attributes)
+	if err != nil {
+		return "", model.NewCustomError(model.CompositeKeyErrorType, list.objectType, err.Error())
+	}
+
+	return key, nil
+}
+
+func (list checkpoints) length(stub shim.ChaincodeStubInterface) (int64, error) {
+
+	lengthKey, err := list.key(stub, "length")
+	if err != nil {
+		return 0, err
+	}
+
+	lengthBytes, err := stub.GetState(lengthKey)
+	if err != nil {
+		return 0, model.NewCustomError(model.GetStateErrorType, list.objectType, err.Error())
+	}
+
+	if lengthBytes == nil {
+		return 0, nil
+	}
+
+	length, err := strconv.ParseInt(string(lengthBytes), 10, 64)
+	if err != nil {
+		return 0, model.NewCustomError(model.ConvertErrorType, list.objectType, err.Error())
+	}
+
+	return length, nil
+}
+
+func (list checkpoints) get(stub shim.ChaincodeStubInterface, index int64) (*model.Checkpoint, error) {
+
+	checkpointKey, err := list.key(stub, fmt.Sprintf("%020d", index))
+	if err != nil {
+		return nil, err
+	}
+
+	checkpointBytes, err := stub.GetState(checkpointKey)
+	if err != nil {
+		return nil, model.NewCustomError(model.GetStateErrorType, list.objectType, err.Error())
+	}
+
+	if checkpointBytes == nil {
+		return nil, model.NewCustomError(model.GetStateErrorType, list.objectType, fmt.Sprintf("checkpoint %d does not exist", index))
+	}
+
+	checkpoint := &model.Checkpoint{}
+	err = json.Unmarshal(checkpointBytes, checkpoint)
+	if err != nil {
+		return nil, model.NewCustomError(model.UnmarshalErrorType, list.objectType, err.Error())
+	}
+
+	return checkpoint, nil
+}
+
+// last returns the latest checkpoint and the length of list, checkpoint is nil if list is empty
+func (list checkpoints) last(stub shim.ChaincodeStubInterface) (*model.Checkpoint, int64, error) {
+
+	length, err := list.length(stub)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if length == 0 {
+		return nil, 0, nil
+	}
+
+	checkpoint, err := list.get(stub, length-1)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return checkpoint, length, nil
+}
+
+// put saves checkpoint at index, index equal to length appends it
+func (list checkpoints) put(stub shim.ChaincodeStubInterface, index, length int64, checkpoint *model.Checkpoint) error {
+
+	checkpointKey, err := list.key(stub, fmt.Sprintf("%020d", index))
+	if err != nil {
+		return err
+	}
+
+	checkpointBytes, err := json.Marshal(checkpoint)
+	if err != nil {
+		return model.NewCustomError(model.MarshalErrorType, list.objectType, err.Error())
+	}
+
+	err = stub.PutState(checkpointKey, checkpointBytes)
+	if err != nil {
+		return model.NewCustomError(model.PutStateErrorType, list.objectType, err.Error())
+	}
+
+	if index < length {
+		return nil
+	}
+
+	lengthKey, err := list.key(stub, "length")
+	if err != nil {
+		return err
+	}
+
+	err = stub.PutState(lengthKey, []byte(strconv.FormatInt(index+1, 10)))
+	if err != nil {
+		return model.NewCustomError(model.PutStateErrorType, list.objectType, err.Error())
+	}
+
+	return nil
+}
+
+// lowerLookup returns the first checkpoint whose key is key or after, nil if there is none
+func (list checkpoints) lowerLookup(stub shim.ChaincodeStubInterface, key int64) (*model.Checkpoint, error) {
+
+	length, err := list.length(stub)
+	if err != nil {
+		return nil, err
+	}
+
+	var found *model.Checkpoint
+	low, high := int64(0), length
+	for low < high {
+		mid := low + (high-low)/2
+		checkpoint, err := list.get(stub, mid)
+		if err != nil {
+			return nil, err
+		}
+
+		if checkpoint.Key >= key {
+			found = checkpoint
+			high = mid
+		} else {
+			low = mid + 1
+		}
+	}
+
+	return found, nil
+}
+
+// upperLookup returns the last checkpoint whose key is key or before, nil if there is none
+func (list checkpoints) upperLookup(stub shim.ChaincodeStubInterface, key int64) (*model.Checkpoint, error) {
+
+	length, err := list.length(stub)
+	if err != nil {
+		return nil, err
+	}
+
+	var found *model.Checkpoint
+	low, high := int64(0), length
+	for low < high {
+		mid := low + (high-low)/2
+		checkpoint, err := list.get(stub, mid)
+		if err != nil {
+			return nil, err
+		}
+
+		if checkpoint.Key <= key {
+			found = checkpoint
+			low = mid + 1
+		} else {
+			high = mid
+		}
+	}
+
+	return found, nil
+}
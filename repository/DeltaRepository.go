@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"hyperledger_dapp/model"
+	"math/big"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+const (
+	DeltaModePrefix    = "deltaMode"
+	BalanceDeltaPrefix = "balanceDelta"
+)
+
+func SaveDeltaMode(stub shim.ChaincodeStubInterface, tokenName, owner string, enabled bool) error {
+
+	// create composite key for delta mode - deltaMode/{tokenName}/{owner}
+	deltaModeKey, err := stub.CreateCompositeKey(DeltaModePrefix, []string{tokenName, owner})
+	if err != nil {
+		return model.NewCustomError(model.CompositeKeyErrorType, DeltaModePrefix, err.Error())
+	}
+
+	if !enabled {
+		err = stub.DelState(deltaModeKey)
+		if err != nil {
+			return model.NewCustomError(model.DelStateErrorType, DeltaModePrefix, err.Error())
+		}
+		return nil
+	}
+
+	err = stub.PutState(deltaModeKey, []byte(owner))
+	if err != nil {
+		return model.NewCustomError(model.PutStateErrorType, DeltaModePrefix, err.Error())
+	}
+
+	return nil
+}
+
+// IsDeltaMode returns true if credits of owner are saved as deltas
+func IsDeltaMode(stub shim.ChaincodeStubInterface, tokenName, owner string) (bool, error) {
+
+	deltaModeKey, err := stub.CreateCompositeKey(DeltaModePrefix, []string{tokenName, owner})
+	if err != nil {
+		return false, model.NewCustomError(model.CompositeKeyErrorType, DeltaModePrefix, err.Error())
+	}
+
+	deltaModeBytes, err := stub.GetState(deltaModeKey)
+	if err != nil {
+		return false, model.NewCustomError(model.GetStateErrorType, DeltaModePrefix, err.Error())
+	}
+
+	return deltaModeBytes != nil, nil
+}
+
+// SaveBalanceDelta credits delta to owner under a key unique to the transaction
+// it does not read the balance, so concurrent credits to owner do not conflict
+func SaveBalanceDelta(stub shim.ChaincodeStubInterface, tokenName, owner string, delta *big.Int) error {
+
+	// keep the balance of the current snapshot, only the first credit after a snapshot reads the balance
+	err := updateSnapshot(stub, tokenName, newCheckpoints(SnapshotPrefix, "balance", tokenName, owner), func() (*big.Int, error) {
+		return GetBalance(stub, tokenName, owner, true)
+	})
+	if err != nil {
+		return err
+	}
+
+	// create composite key for delta - balanceDelta/{tokenName}/{owner}/{txId}
+	deltaKey, err := stub.CreateCompositeKey(BalanceDeltaPrefix, []string{tokenName, owner, stub.GetTxID()})
+	if err != nil {
+		return model.NewCustomError(model.CompositeKeyErrorType, BalanceDeltaPrefix, err.Error())
+	}
+
+	err = stub.PutState(deltaKey, []byte(delta.String()))
+	if err != nil {
+		return model.NewCustomError(model.PutStateErrorType, BalanceDeltaPrefix, err.Error())
+	}
+
+	return nil
+}
+
+// getBalanceDeltas returns the keys and the sum of the deltas of owner
+func getBalanceDeltas(stub shim.ChaincodeStubInterface, tokenName, owner string) ([]string, *big.Int, error) {
+
+	// get all deltas of owner (format is iterator)
+	deltaIterator, err := stub.GetStateByPartialCompositeKey(BalanceDeltaPrefix, []string{tokenName, owner})
+	if err != nil {
+		return nil, nil, model.NewCustomError(model.GetStateErrorType, BalanceDeltaPrefix, err.Error())
+	}
+	defer deltaIterator.Close()
+
+	deltaKeys := []string{}
+	deltaSum := new(big.Int)
+	for deltaIterator.HasNext() {
+		deltaKV, err := deltaIterator.Next()
+		if err != nil {
+			return nil, nil, model.NewCustomError(model.GetStateErrorType, BalanceDeltaPrefix, err.Error())
+		}
+
+		delta, ok := new(big.Int).SetString(string(deltaKV.GetValue()), 10)
+		if !ok {
+			return nil, nil, model.NewCustomError(model.ConvertErrorType, BalanceDeltaPrefix, "invalid delta "+string(deltaKV.GetValue()))
+		}
+
+		deltaKeys = append(deltaKeys, deltaKV.GetKey())
+		deltaSum.Add(deltaSum, delta)
+	}
+
+	return deltaKeys, deltaSum, nil
+}
@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"encoding/json"
+	"hyperledger_dapp/model"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+const (
+	DistributionPrefix  = "distribution"
+	DividendClaimPrefix = "dividendClaim"
+)
+
+func SaveDistribution(stub shim.ChaincodeStubInterface, distribution *model.Distribution) error {
+
+	// create composite key for distribution - distribution/{id}
+	distributionKey, err := stub.CreateCompositeKey(DistributionPrefix, []string{distribution.ID})
+	if err != nil {
+		return model.NewCustomError(model.CompositeKeyErrorType, DistributionPrefix, err.Error())
+	}
+
+	distributionBytes, err := json.Marshal(distribution)
+	if err != nil {
+		return model.NewCustomError(model.MarshalErrorType, DistributionPrefix, err.Error())
+	}
+
+	err = stub.PutState(distributionKey, distributionBytes)
+	if err != nil {
+		return model.NewCustomError(model.PutStateErrorType, DistributionPrefix, err.Error())
+	}
+
+	return nil
+}
+
+func GetDistribution(stub shim.ChaincodeStubInterface, id string) (*model.Distribution, error) {
+
+	distributionKey, err := stub.CreateCompositeKey(DistributionPrefix, []string{id})
+	if err != nil {
+		return nil, model.NewCustomError(model.CompositeKeyErrorType, DistributionPrefix, err.Error())
+	}
+
+	distributionBytes, err := stub.GetState(distributionKey)
+	if err != nil {
+		return nil, model.NewCustomError(model.GetStateErrorType, DistributionPrefix, err.Error())
+	}
+
+	if distributionBytes == nil {
+		return nil, model.NewCustomError(model.GetStateErrorType, DistributionPrefix, id+" does not exist")
+	}
+
+	distribution := &model.Distribution{}
+	err = json.Unmarshal(distributionBytes, distribution)
+	if err != nil {
+		return nil, model.NewCustomError(model.UnmarshalErrorType, DistributionPrefix, err.Error())
+	}
+
+	return distribution, nil
+}
+
+func SaveDividendClaim(stub shim.ChaincodeStubInterface, id, holder string, claim *model.DividendClaim) error {
+
+	// create composite key for claim - dividendClaim/{id}/{holder}
+	claimKey, err := stub.CreateCompositeKey(DividendClaimPrefix, []string{id, holder})
+	if err != nil {
+		return model.NewCustomError(model.CompositeKeyErrorType, DividendClaimPrefix, err.Error())
+	}
+
+	claimBytes, err := json.Marshal(claim)
+	if err != nil {
+		return model.NewCustomError(model.MarshalErrorType, DividendClaimPrefix, err.Error())
+	}
+
+	err = stub.PutState(claimKey, claimBytes)
+	if err != nil {
+		return model.NewCustomError(model.PutStateErrorType, DividendClaimPrefix, err.Error())
+	}
+
+	return nil
+}
+
+// IsDividendClaimed returns true if holder claimed the distribution
+func IsDividendClaimed(stub shim.ChaincodeStubInterface, id, holder string) (bool, error) {
+
+	claimKey, err := stub.CreateCompositeKey(DividendClaimPrefix, []string{id, holder})
+	if err != nil {
+		return false, model.NewCustomError(model.CompositeKeyErrorType, DividendClaimPrefix, err.Error())
+	}
+
+	claimBytes, err := stub.GetState(claimKey)
+	if err != nil {
+		return false, model.NewCustomError(model.GetStateErrorType, DividendClaimPrefix, err.Error())
+	}
+
+	return claimBytes != nil, nil
+}
+
+// ListDividendClaims returns the claims of the distribution
+func ListDividendClaims(stub shim.ChaincodeStubInterface, id string) ([]*model.DividendClaim, error) {
+
+	// get all claims of the distribution (format is iterator)
+	claimIterator, err := stub.GetStateByPartialCompositeKey(DividendClaimPrefix, []string{id})
+	if err != nil {
+		return nil, model.NewCustomError(model.GetStateErrorType, DividendClaimPrefix, err.Error())
+	}
+	defer claimIterator.Close()
+
+	claims := []*model.DividendClaim{}
+	for claimIterator.HasNext() {
+		claimKV, err := claimIterator.Next()
+		if err != nil {
+			return nil, model.NewCustomError(model.GetStateErrorType, DividendClaimPrefix, err.Error())
+		}
+
+		claim := &model.DividendClaim{}
+		err = json.Unmarshal(claimKV.GetValue(), claim)
+		if err != nil {
+			return nil, model.NewCustomError(model.UnmarshalErrorType, DividendClaimPrefix, err.Error())
+		}
+		claims = append(claims, claim)
+	}
+
+	return claims, nil
+}
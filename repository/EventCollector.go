@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"encoding/json"
+	"hyperledger_dapp/model"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+// EnvelopeEventKey is the name of the event carrying every event of a transaction
+const EnvelopeEventKey = "eventEnvelope"
+
+// EventCollector wraps stub and keeps every event set during the transaction
+// fabric keeps only the last SetEvent of a transaction, so the events are emitted together by Flush
+type EventCollector struct {
+	shim.ChaincodeStubInterface
+	events []model.EventRecord
+}
+
+func NewEventCollector(stub shim.ChaincodeStubInterface) *EventCollector {
+	return &EventCollector{ChaincodeStubInterface: stub, events: []model.EventRecord{}}
+}
+
+// SetEvent collects the event instead of setting it on the transaction
+func (collector *EventCollector) SetEvent(name string, payload []byte) error {
+
+	if name == "" {
+		return model.NewCustomError(model.SetEventErrorType, "event", "event name can not be empty")
+	}
+
+	if !json.Valid(payload) {
+		return model.NewCustomError(model.SetEventErrorType, name, "payload must be json")
+	}
+
+	collector.events = append(collector.events, model.EventRecord{Name: name, Payload: payload})
+	return nil
+}
+
+// Events returns the events collected so far
+func (collector *EventCollector) Events() []model.EventRecord {
+	return collector.events
+}
+
+// Flush emits the collected events as one envelope
+// nothing is emitted if no event was collected
+func (collector *EventCollector) Flush() error {
+
+	if len(collector.events) == 0 {
+		return nil
+	}
+
+	envelopeBytes, err := json.Marshal(model.NewEventEnvelope(collector.events))
+	if err != nil {
+		return model.NewCustomError(model.MarshalErrorType, EnvelopeEventKey, err.Error())
+	}
+
+	err = collector.ChaincodeStubInterface.SetEvent(EnvelopeEventKey, envelopeBytes)
+	if err != nil {
+		return model.NewCustomError(model.SetEventErrorType, EnvelopeEventKey, err.Error())
+	}
+
+	return nil
+}
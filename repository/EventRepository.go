@@ -3,17 +3,36 @@ package repository
 import (
 	"encoding/json"
 	"hyperledger_dapp/model"
+	"math/big"
 
 	"github.com/hyperledger/fabric-chaincode-go/shim"
 )
 
 const (
-	TransferEventKey = "transferEvent"
-	ApprovalEventKey = "approvalEvent"
+	TransferEventKey      = "transferEvent"
+	ApprovalEventKey      = "approvalEvent"
+	PauseEventKey         = "pauseEvent"
+	UnpauseEventKey       = "unpauseEvent"
+	HTLCLockEventKey      = "htlcLockEvent"
+	HTLCClaimEventKey     = "htlcClaimEvent"
+	HTLCRefundEventKey    = "htlcRefundEvent"
+	SnapshotEventKey      = "snapshotEvent"
+	BatchTransferEventKey = "batchTransferEvent"
+	AirdropEventKey       = "airdropEvent"
+	FreezeEventKey        = "freezeEvent"
+	UnfreezeEventKey      = "unfreezeEvent"
 )
 
-func EmitTransferEvent(stub shim.ChaincodeStubInterface, sender, spender string, amount int) error {
-	transferEvent := model.NewTransferEvent(sender, spender, amount)
+func EmitTransferEvent(stub shim.ChaincodeStubInterface, tokenName, sender, spender string, amount *big.Int) error {
+	return emitTransferEvent(stub, model.NewTransferEvent(tokenName, sender, spender, amount))
+}
+
+// EmitTransferFeeEvent emits transfer event with the fee paid to treasury
+func EmitTransferFeeEvent(stub shim.ChaincodeStubInterface, tokenName, sender, recipient string, amount, fee *big.Int, treasury string) error {
+	return emitTransferEvent(stub, model.NewTransferFeeEvent(tokenName, sender, recipient, amount, fee, treasury))
+}
+
+func emitTransferEvent(stub shim.ChaincodeStubInterface, transferEvent *model.TransferEvent) error {
 	transferEventBytes, err := json.Marshal(transferEvent)
 	if err != nil {
 		return model.NewCustomError(model.MarshalErrorType, TransferEventKey, err.Error())
@@ -26,8 +45,8 @@ func EmitTransferEvent(stub shim.ChaincodeStubInterface, sender, spender string,
 	return nil
 }
 
-func EmitApprovalEvent(stub shim.ChaincodeStubInterface, owner, spender string, allowance int) error {
-	approvalEvent := model.NewApproval(owner, spender, allowance)
+func EmitApprovalEvent(stub shim.ChaincodeStubInterface, tokenName, owner, spender string, allowance *big.Int) error {
+	approvalEvent := model.NewApproval(tokenName, spender, owner, allowance)
 	approvalBytes, err := json.Marshal(approvalEvent)
 	if err != nil {
 		return model.NewCustomError(model.MarshalErrorType, ApprovalEventKey, err.Error())
@@ -40,3 +59,76 @@ func EmitApprovalEvent(stub shim.ChaincodeStubInterface, owner, spender string,
 
 	return nil
 }
+
+func EmitPauseEvent(stub shim.ChaincodeStubInterface, eventKey, account string) error {
+	pauseEvent := model.NewPauseEvent(account)
+	pauseBytes, err := json.Marshal(pauseEvent)
+	if err != nil {
+		return model.NewCustomError(model.MarshalErrorType, eventKey, err.Error())
+	}
+
+	err = stub.SetEvent(eventKey, pauseBytes)
+	if err != nil {
+		return model.NewCustomError(model.SetEventErrorType, eventKey, err.Error())
+	}
+
+	return nil
+}
+
+func EmitFreezeEvent(stub shim.ChaincodeStubInterface, eventKey, address, account string) error {
+	freezeEvent := model.NewFreezeEvent(address, account)
+	freezeBytes, err := json.Marshal(freezeEvent)
+	if err != nil {
+		return model.NewCustomError(model.MarshalErrorType, eventKey, err.Error())
+	}
+
+	err = stub.SetEvent(eventKey, freezeBytes)
+	if err != nil {
+		return model.NewCustomError(model.SetEventErrorType, eventKey, err.Error())
+	}
+
+	return nil
+}
+
+func EmitHTLCEvent(stub shim.ChaincodeStubInterface, eventKey string, htlc *model.HTLC) error {
+	htlcBytes, err := json.Marshal(htlc)
+	if err != nil {
+		return model.NewCustomError(model.MarshalErrorType, eventKey, err.Error())
+	}
+
+	err = stub.SetEvent(eventKey, htlcBytes)
+	if err != nil {
+		return model.NewCustomError(model.SetEventErrorType, eventKey, err.Error())
+	}
+
+	return nil
+}
+
+func EmitSnapshotEvent(stub shim.ChaincodeStubInterface, tokenName string, id int64) error {
+	snapshotEvent := model.NewSnapshotEvent(tokenName, id)
+	snapshotBytes, err := json.Marshal(snapshotEvent)
+	if err != nil {
+		return model.NewCustomError(model.MarshalErrorType, SnapshotEventKey, err.Error())
+	}
+
+	err = stub.SetEvent(SnapshotEventKey, snapshotBytes)
+	if err != nil {
+		return model.NewCustomError(model.SetEventErrorType, SnapshotEventKey, err.Error())
+	}
+
+	return nil
+}
+
+func EmitBatchTransferEvent(stub shim.ChaincodeStubInterface, eventKey string, batchTransferEvent *model.BatchTransferEvent) error {
+	batchTransferBytes, err := json.Marshal(batchTransferEvent)
+	if err != nil {
+		return model.NewCustomError(model.MarshalErrorType, eventKey, err.Error())
+	}
+
+	err = stub.SetEvent(eventKey, batchTransferBytes)
+	if err != nil {
+		return model.NewCustomError(model.SetEventErrorType, eventKey, err.Error())
+	}
+
+	return nil
+}
@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"encoding/json"
+	"hyperledger_dapp/model"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+const (
+	FeeConfigPrefix = "feeConfig"
+	FeeExemptPrefix = "feeExempt"
+)
+
+func SaveFeeConfig(stub shim.ChaincodeStubInterface, config *model.FeeConfig) error {
+
+	// create composite key for fee config - feeConfig/{tokenName}
+	configKey, err := stub.CreateCompositeKey(FeeConfigPrefix, []string{config.Token})
+	if err != nil {
+		return model.NewCustomError(model.CompositeKeyErrorType, FeeConfigPrefix, err.Error())
+	}
+
+	configBytes, err := json.Marshal(config)
+	if err != nil {
+		return model.NewCustomError(model.MarshalErrorType, FeeConfigPrefix, err.Error())
+	}
+
+	err = stub.PutState(configKey, configBytes)
+	if err != nil {
+		return model.NewCustomError(model.PutStateErrorType, FeeConfigPrefix, err.Error())
+	}
+
+	return nil
+}
+
+// GetFeeConfig returns nil if token charges no fee
+func GetFeeConfig(stub shim.ChaincodeStubInterface, tokenName string) (*model.FeeConfig, error) {
+
+	configKey, err := stub.CreateCompositeKey(FeeConfigPrefix, []string{tokenName})
+	if err != nil {
+		return nil, model.NewCustomError(model.CompositeKeyErrorType, FeeConfigPrefix, err.Error())
+	}
+
+	configBytes, err := stub.GetState(configKey)
+	if err != nil {
+		return nil, model.NewCustomError(model.GetStateErrorType, FeeConfigPrefix, err.Error())
+	}
+
+	if configBytes == nil {
+		return nil, nil
+	}
+
+	config := &model.FeeConfig{}
+	err = json.Unmarshal(configBytes, config)
+	if err != nil {
+		return nil, model.NewCustomError(model.UnmarshalErrorType, FeeConfigPrefix, err.Error())
+	}
+
+	return config, nil
+}
+
+func SaveFeeExempt(stub shim.ChaincodeStubInterface, tokenName, address string, exempt bool) error {
+
+	// create composite key for fee exempt - feeExempt/{tokenName}/{address}
+	exemptKey, err := stub.CreateCompositeKey(FeeExemptPrefix, []string{tokenName, address})
+	if err != nil {
+		return model.NewCustomError(model.CompositeKeyErrorType, FeeExemptPrefix, err.Error())
+	}
+
+	if !exempt {
+		err = stub.DelState(exemptKey)
+		if err != nil {
+			return model.NewCustomError(model.DelStateErrorType, FeeExemptPrefix, err.Error())
+		}
+		return nil
+	}
+
+	err = stub.PutState(exemptKey, []byte(address))
+	if err != nil {
+		return model.NewCustomError(model.PutStateErrorType, FeeExemptPrefix, err.Error())
+	}
+
+	return nil
+}
+
+func IsFeeExempt(stub shim.ChaincodeStubInterface, tokenName, address string) (bool, error) {
+
+	exemptKey, err := stub.CreateCompositeKey(FeeExemptPrefix, []string{tokenName, address})
+	if err != nil {
+		return false, model.NewCustomError(model.CompositeKeyErrorType, FeeExemptPrefix, err.Error())
+	}
+
+	exemptBytes, err := stub.GetState(exemptKey)
+	if err != nil {
+		return false, model.NewCustomError(model.GetStateErrorType, FeeExemptPrefix, err.Error())
+	}
+
+	return exemptBytes != nil, nil
+}
+
+// ListFeeExempt returns fee exempt addresses of token
+func ListFeeExempt(stub shim.ChaincodeStubInterface, tokenName string) ([]string, error) {
+
+	// get all exempt addresses of token (format is iterator)
+	exemptIterator, err := stub.GetStateByPartialCompositeKey(FeeExemptPrefix, []string{tokenName})
+	if err != nil {
+		return nil, model.NewCustomError(model.GetStateErrorType, FeeExemptPrefix, err.Error())
+	}
+	defer exemptIterator.Close()
+
+	addresses := []string{}
+	for exemptIterator.HasNext() {
+		exemptKV, err := exemptIterator.Next()
+		if err != nil {
+			return nil, model.NewCustomError(model.GetStateErrorType, FeeExemptPrefix, err.Error())
+		}
+		addresses = append(addresses, string(exemptKV.GetValue()))
+	}
+
+	return addresses, nil
+}
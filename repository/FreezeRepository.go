@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"encoding/json"
+	"hyperledger_dapp/model"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+const FrozenPrefix = "frozen"
+
+func SaveFreezeRecord(stub shim.ChaincodeStubInterface, record *model.FreezeRecord) error {
+
+	// create composite key for freeze record - frozen/{address}
+	frozenKey, err := stub.CreateCompositeKey(FrozenPrefix, []string{record.Address})
+	if err != nil {
+		return model.NewCustomError(model.CompositeKeyErrorType, FrozenPrefix, err.Error())
+	}
+
+	recordBytes, err := json.Marshal(record)
+	if err != nil {
+		return model.NewCustomError(model.MarshalErrorType, FrozenPrefix, err.Error())
+	}
+
+	err = stub.PutState(frozenKey, recordBytes)
+	if err != nil {
+		return model.NewCustomError(model.PutStateErrorType, FrozenPrefix, err.Error())
+	}
+
+	return nil
+}
+
+func DeleteFreezeRecord(stub shim.ChaincodeStubInterface, address string) error {
+
+	frozenKey, err := stub.CreateCompositeKey(FrozenPrefix, []string{address})
+	if err != nil {
+		return model.NewCustomError(model.CompositeKeyErrorType, FrozenPrefix, err.Error())
+	}
+
+	err = stub.DelState(frozenKey)
+	if err != nil {
+		return model.NewCustomError(model.DelStateErrorType, FrozenPrefix, err.Error())
+	}
+
+	return nil
+}
+
+func IsFrozen(stub shim.ChaincodeStubInterface, address string) (bool, error) {
+
+	frozenKey, err := stub.CreateCompositeKey(FrozenPrefix, []string{address})
+	if err != nil {
+		return false, model.NewCustomError(model.CompositeKeyErrorType, FrozenPrefix, err.Error())
+	}
+
+	recordBytes, err := stub.GetState(frozenKey)
+	if err != nil {
+		return false, model.NewCustomError(model.GetStateErrorType, FrozenPrefix, err.Error())
+	}
+
+	return recordBytes != nil, nil
+}
+
+func ListFreezeRecords(stub shim.ChaincodeStubInterface) ([]model.FreezeRecord, error) {
+
+	// get all freeze records (format is iterator)
+	frozenIterator, err := stub.GetStateByPartialCompositeKey(FrozenPrefix, []string{})
+	if err != nil {
+		return nil, model.NewCustomError(model.GetStateErrorType, FrozenPrefix, err.Error())
+	}
+	defer frozenIterator.Close()
+
+	records := []model.FreezeRecord{}
+	for frozenIterator.HasNext() {
+		frozenKV, err := frozenIterator.Next()
+		if err != nil {
+			return nil, model.NewCustomError(model.GetStateErrorType, FrozenPrefix, err.Error())
+		}
+
+		record := model.FreezeRecord{}
+		err = json.Unmarshal(frozenKV.GetValue(), &record)
+		if err != nil {
+			return nil, model.NewCustomError(model.UnmarshalErrorType, FrozenPrefix, err.Error())
+		}
+		records = append(records, record)
+	}
+
+	return records, nil
+}
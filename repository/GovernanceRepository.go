@@ -0,0 +1,214 @@
+package repository
+
+import (
+	"encoding/json"
+	"hyperledger_dapp/model"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+const (
+	GovernanceConfigPrefix = "governanceConfig"
+	GovernanceTokenPrefix  = "governanceToken"
+	ProposalPrefix         = "proposal"
+	VotePrefix             = "vote"
+)
+
+// default quorum is 4% of total supply, default threshold is majority
+// and the proposer must hold 1% of total supply by default
+const (
+	DefaultQuorum            = 400
+	DefaultThreshold         = 5000
+	DefaultProposalThreshold = 100
+)
+
+func SaveGovernanceConfig(stub shim.ChaincodeStubInterface, config *model.GovernanceConfig) error {
+
+	// create composite key for config - governanceConfig/{tokenName}
+	configKey, err := stub.CreateCompositeKey(GovernanceConfigPrefix, []string{config.Token})
+	if err != nil {
+		return model.NewCustomError(model.CompositeKeyErrorType, GovernanceConfigPrefix, err.Error())
+	}
+
+	configBytes, err := json.Marshal(config)
+	if err != nil {
+		return model.NewCustomError(model.MarshalErrorType, GovernanceConfigPrefix, err.Error())
+	}
+
+	err = stub.PutState(configKey, configBytes)
+	if err != nil {
+		return model.NewCustomError(model.PutStateErrorType, GovernanceConfigPrefix, err.Error())
+	}
+
+	return nil
+}
+
+// GetGovernanceConfig returns the default config if token has no config
+func GetGovernanceConfig(stub shim.ChaincodeStubInterface, tokenName string) (*model.GovernanceConfig, error) {
+
+	configKey, err := stub.CreateCompositeKey(GovernanceConfigPrefix, []string{tokenName})
+	if err != nil {
+		return nil, model.NewCustomError(model.CompositeKeyErrorType, GovernanceConfigPrefix, err.Error())
+	}
+
+	configBytes, err := stub.GetState(configKey)
+	if err != nil {
+		return nil, model.NewCustomError(model.GetStateErrorType, GovernanceConfigPrefix, err.Error())
+	}
+
+	if configBytes == nil {
+		return model.NewGovernanceConfig(tokenName, DefaultQuorum, DefaultThreshold, DefaultProposalThreshold), nil
+	}
+
+	config := &model.GovernanceConfig{}
+	err = json.Unmarshal(configBytes, config)
+	if err != nil {
+		return nil, model.NewCustomError(model.UnmarshalErrorType, GovernanceConfigPrefix, err.Error())
+	}
+
+	return config, nil
+}
+
+func SaveGovernanceToken(stub shim.ChaincodeStubInterface, tokenName string) error {
+
+	// create composite key for governance token - governanceToken
+	governanceTokenKey, err := stub.CreateCompositeKey(GovernanceTokenPrefix, []string{})
+	if err != nil {
+		return model.NewCustomError(model.CompositeKeyErrorType, GovernanceTokenPrefix, err.Error())
+	}
+
+	err = stub.PutState(governanceTokenKey, []byte(tokenName))
+	if err != nil {
+		return model.NewCustomError(model.PutStateErrorType, GovernanceTokenPrefix, err.Error())
+	}
+
+	return nil
+}
+
+// GetGovernanceToken returns the token whose proposals can run chaincode wide actions
+// empty string means no token is designated
+func GetGovernanceToken(stub shim.ChaincodeStubInterface) (string, error) {
+
+	governanceTokenKey, err := stub.CreateCompositeKey(GovernanceTokenPrefix, []string{})
+	if err != nil {
+		return "", model.NewCustomError(model.CompositeKeyErrorType, GovernanceTokenPrefix, err.Error())
+	}
+
+	governanceTokenBytes, err := stub.GetState(governanceTokenKey)
+	if err != nil {
+		return "", model.NewCustomError(model.GetStateErrorType, GovernanceTokenPrefix, err.Error())
+	}
+
+	return string(governanceTokenBytes), nil
+}
+
+func SaveProposal(stub shim.ChaincodeStubInterface, proposal *model.Proposal) error {
+
+	// create composite key for proposal - proposal/{id}
+	proposalKey, err := stub.CreateCompositeKey(ProposalPrefix, []string{proposal.ID})
+	if err != nil {
+		return model.NewCustomError(model.CompositeKeyErrorType, ProposalPrefix, err.Error())
+	}
+
+	proposalBytes, err := json.Marshal(proposal)
+	if err != nil {
+		return model.NewCustomError(model.MarshalErrorType, ProposalPrefix, err.Error())
+	}
+
+	err = stub.PutState(proposalKey, proposalBytes)
+	if err != nil {
+		return model.NewCustomError(model.PutStateErrorType, ProposalPrefix, err.Error())
+	}
+
+	return nil
+}
+
+func GetProposal(stub shim.ChaincodeStubInterface, id string) (*model.Proposal, error) {
+
+	proposalKey, err := stub.CreateCompositeKey(ProposalPrefix, []string{id})
+	if err != nil {
+		return nil, model.NewCustomError(model.CompositeKeyErrorType, ProposalPrefix, err.Error())
+	}
+
+	proposalBytes, err := stub.GetState(proposalKey)
+	if err != nil {
+		return nil, model.NewCustomError(model.GetStateErrorType, ProposalPrefix, err.Error())
+	}
+
+	if proposalBytes == nil {
+		return nil, model.NewCustomError(model.GetStateErrorType, ProposalPrefix, id+" does not exist")
+	}
+
+	proposal := &model.Proposal{}
+	err = json.Unmarshal(proposalBytes, proposal)
+	if err != nil {
+		return nil, model.NewCustomError(model.UnmarshalErrorType, ProposalPrefix, err.Error())
+	}
+
+	return proposal, nil
+}
+
+// SaveVote saves vote under its own key, so voters do not conflict on the proposal key
+func SaveVote(stub shim.ChaincodeStubInterface, proposalID string, vote *model.Vote) error {
+
+	// create composite key for vote - vote/{proposalId}/{voter}
+	voteKey, err := stub.CreateCompositeKey(VotePrefix, []string{proposalID, vote.Voter})
+	if err != nil {
+		return model.NewCustomError(model.CompositeKeyErrorType, VotePrefix, err.Error())
+	}
+
+	voteBytes, err := json.Marshal(vote)
+	if err != nil {
+		return model.NewCustomError(model.MarshalErrorType, VotePrefix, err.Error())
+	}
+
+	err = stub.PutState(voteKey, voteBytes)
+	if err != nil {
+		return model.NewCustomError(model.PutStateErrorType, VotePrefix, err.Error())
+	}
+
+	return nil
+}
+
+// HasVoted returns true if voter voted on proposal
+func HasVoted(stub shim.ChaincodeStubInterface, proposalID, voter string) (bool, error) {
+
+	voteKey, err := stub.CreateCompositeKey(VotePrefix, []string{proposalID, voter})
+	if err != nil {
+		return false, model.NewCustomError(model.CompositeKeyErrorType, VotePrefix, err.Error())
+	}
+
+	voteBytes, err := stub.GetState(voteKey)
+	if err != nil {
+		return false, model.NewCustomError(model.GetStateErrorType, VotePrefix, err.Error())
+	}
+
+	return voteBytes != nil, nil
+}
+
+func ListVotes(stub shim.ChaincodeStubInterface, proposalID string) ([]model.Vote, error) {
+
+	// get all votes of proposal (format is iterator)
+	voteIterator, err := stub.GetStateByPartialCompositeKey(VotePrefix, []string{proposalID})
+	if err != nil {
+		return nil, model.NewCustomError(model.GetStateErrorType, VotePrefix, err.Error())
+	}
+	defer voteIterator.Close()
+
+	votes := []model.Vote{}
+	for voteIterator.HasNext() {
+		voteKV, err := voteIterator.Next()
+		if err != nil {
+			return nil, model.NewCustomError(model.GetStateErrorType, VotePrefix, err.Error())
+		}
+
+		vote := model.Vote{}
+		err = json.Unmarshal(voteKV.GetValue(), &vote)
+		if err != nil {
+			return nil, model.NewCustomError(model.UnmarshalErrorType, VotePrefix, err.Error())
+		}
+		votes = append(votes, vote)
+	}
+
+	return votes, nil
+}
@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"encoding/json"
+	"hyperledger_dapp/model"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+const (
+	HTLCPrefix      = "htlc"
+	HTLCPartyPrefix = "htlcParty"
+)
+
+// SaveHTLC saves the lock and keeps the party index of open locks
+func SaveHTLC(stub shim.ChaincodeStubInterface, htlc *model.HTLC) error {
+
+	// create composite key for htlc - htlc/{id}
+	htlcKey, err := stub.CreateCompositeKey(HTLCPrefix, []string{htlc.ID})
+	if err != nil {
+		return model.NewCustomError(model.CompositeKeyErrorType, HTLCPrefix, err.Error())
+	}
+
+	htlcBytes, err := json.Marshal(htlc)
+	if err != nil {
+		return model.NewCustomError(model.MarshalErrorType, HTLCPrefix, err.Error())
+	}
+
+	err = stub.PutState(htlcKey, htlcBytes)
+	if err != nil {
+		return model.NewCustomError(model.PutStateErrorType, HTLCPrefix, err.Error())
+	}
+
+	// create composite key for party index - htlcParty/{address}/{id}
+	for _, party := range []string{htlc.Sender, htlc.Recipient} {
+		partyKey, err := stub.CreateCompositeKey(HTLCPartyPrefix, []string{party, htlc.ID})
+		if err != nil {
+			return model.NewCustomError(model.CompositeKeyErrorType, HTLCPartyPrefix, err.Error())
+		}
+
+		// closed locks leave the index
+		if htlc.Status != model.HTLCOpen {
+			err = stub.DelState(partyKey)
+			if err != nil {
+				return model.NewCustomError(model.DelStateErrorType, HTLCPartyPrefix, err.Error())
+			}
+			continue
+		}
+
+		err = stub.PutState(partyKey, []byte(htlc.ID))
+		if err != nil {
+			return model.NewCustomError(model.PutStateErrorType, HTLCPartyPrefix, err.Error())
+		}
+	}
+
+	return nil
+}
+
+func GetHTLC(stub shim.ChaincodeStubInterface, id string) (*model.HTLC, error) {
+
+	htlcKey, err := stub.CreateCompositeKey(HTLCPrefix, []string{id})
+	if err != nil {
+		return nil, model.NewCustomError(model.CompositeKeyErrorType, HTLCPrefix, err.Error())
+	}
+
+	htlcBytes, err := stub.GetState(htlcKey)
+	if err != nil {
+		return nil, model.NewCustomError(model.GetStateErrorType, HTLCPrefix, err.Error())
+	}
+
+	if htlcBytes == nil {
+		return nil, model.NewCustomError(model.GetStateErrorType, HTLCPrefix, id+" does not exist")
+	}
+
+	htlc := &model.HTLC{}
+	err = json.Unmarshal(htlcBytes, htlc)
+	if err != nil {
+		return nil, model.NewCustomError(model.UnmarshalErrorType, HTLCPrefix, err.Error())
+	}
+
+	return htlc, nil
+}
+
+// ListOpenHTLCs returns open locks where address is sender or recipient
+func ListOpenHTLCs(stub shim.ChaincodeStubInterface, address string) ([]model.HTLC, error) {
+
+	// get lock ids of address (format is iterator)
+	partyIterator, err := stub.GetStateByPartialCompositeKey(HTLCPartyPrefix, []string{address})
+	if err != nil {
+		return nil, model.NewCustomError(model.GetStateErrorType, HTLCPartyPrefix, err.Error())
+	}
+	defer partyIterator.Close()
+
+	htlcs := []model.HTLC{}
+	for partyIterator.HasNext() {
+		partyKV, err := partyIterator.Next()
+		if err != nil {
+			return nil, model.NewCustomError(model.GetStateErrorType, HTLCPartyPrefix, err.Error())
+		}
+
+		htlc, err := GetHTLC(stub, string(partyKV.GetValue()))
+		if err != nil {
+			return nil, err
+		}
+		htlcs = append(htlcs, *htlc)
+	}
+
+	return htlcs, nil
+}
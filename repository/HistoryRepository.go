@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"hyperledger_dapp/model"
+	"strconv"
+	"strings"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+const HistoryPrefix = "history"
+
+// GetHistory returns at most pageSize modifications of key, starting after bookmark
+// bookmark is "timestamp:txId" of the last modification returned, so modifications written
+// between page requests do not shift the next page
+func GetHistory(stub shim.ChaincodeStubInterface, key string, pageSize int32, bookmark string) (*model.HistoryPage, error) {
+
+	var afterTimestamp int64
+	afterTxID := ""
+	if bookmark != "" {
+		parts := strings.SplitN(bookmark, ":", 2)
+		timestamp, err := strconv.ParseInt(parts[0], 10, 64)
+		if err != nil || len(parts) != 2 || parts[1] == "" {
+			return nil, model.NewCustomError(model.ConvertErrorType, "bookmark", "bookmark must be timestamp:txId")
+		}
+		afterTimestamp, afterTxID = timestamp, parts[1]
+	}
+
+	historyIterator, err := stub.GetHistoryForKey(key)
+	if err != nil {
+		return nil, model.NewCustomError(model.GetStateErrorType, HistoryPrefix, err.Error())
+	}
+	defer historyIterator.Close()
+
+	page := &model.HistoryPage{Entries: []model.HistoryEntry{}}
+	found := afterTxID == ""
+	for historyIterator.HasNext() {
+		modification, err := historyIterator.Next()
+		if err != nil {
+			return nil, model.NewCustomError(model.GetStateErrorType, HistoryPrefix, err.Error())
+		}
+
+		// skip entries up to the last one of the previous page
+		if !found {
+			found = modification.GetTxId() == afterTxID && modification.GetTimestamp().GetSeconds() == afterTimestamp
+			continue
+		}
+
+		// more entries remain, return bookmark of the last entry
+		if int32(len(page.Entries)) == pageSize {
+			last := page.Entries[len(page.Entries)-1]
+			page.Bookmark = strconv.FormatInt(last.Timestamp, 10) + ":" + last.TxID
+			break
+		}
+
+		entry := model.NewHistoryEntry(modification.GetTxId(), modification.GetTimestamp().GetSeconds(),
+			string(modification.GetValue()), modification.GetIsDelete())
+		page.Entries = append(page.Entries, *entry)
+	}
+
+	if !found {
+		return nil, model.NewCustomError(model.GetStateErrorType, HistoryPrefix, "bookmark "+bookmark+" is not in the history of key")
+	}
+
+	return page, nil
+}
@@ -0,0 +1,168 @@
+package repository
+
+import (
+	"encoding/json"
+	"hyperledger_dapp/model"
+	"math/big"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+const (
+	SigningKeyPrefix        = "signingKey"
+	SigningKeyAddressPrefix = "signingKeyAddress"
+	NoncePrefix             = "nonce"
+)
+
+// SaveSigningKey binds the key to address, replacing the previous key of address
+func SaveSigningKey(stub shim.ChaincodeStubInterface, signingKey *model.SigningKey) error {
+
+	// create composite key for signing key - signingKey/{address}
+	signingKeyKey, err := stub.CreateCompositeKey(SigningKeyPrefix, []string{signingKey.Address})
+	if err != nil {
+		return model.NewCustomError(model.CompositeKeyErrorType, SigningKeyPrefix, err.Error())
+	}
+
+	// remove reverse index of the previous key
+	previousBytes, err := stub.GetState(signingKeyKey)
+	if err != nil {
+		return model.NewCustomError(model.GetStateErrorType, SigningKeyPrefix, err.Error())
+	}
+
+	if previousBytes != nil {
+		previous := &model.SigningKey{}
+		err = json.Unmarshal(previousBytes, previous)
+		if err != nil {
+			return model.NewCustomError(model.UnmarshalErrorType, SigningKeyPrefix, err.Error())
+		}
+
+		previousAddressKey, err := stub.CreateCompositeKey(SigningKeyAddressPrefix, []string{previous.Curve, previous.PublicKey})
+		if err != nil {
+			return model.NewCustomError(model.CompositeKeyErrorType, SigningKeyAddressPrefix, err.Error())
+		}
+
+		err = stub.DelState(previousAddressKey)
+		if err != nil {
+			return model.NewCustomError(model.DelStateErrorType, SigningKeyAddressPrefix, err.Error())
+		}
+	}
+
+	// create composite key for reverse index - signingKeyAddress/{curve}/{publicKey}
+	addressKey, err := stub.CreateCompositeKey(SigningKeyAddressPrefix, []string{signingKey.Curve, signingKey.PublicKey})
+	if err != nil {
+		return model.NewCustomError(model.CompositeKeyErrorType, SigningKeyAddressPrefix, err.Error())
+	}
+
+	// a key is bound to one address only
+	boundBytes, err := stub.GetState(addressKey)
+	if err != nil {
+		return model.NewCustomError(model.GetStateErrorType, SigningKeyAddressPrefix, err.Error())
+	}
+
+	if boundBytes != nil && string(boundBytes) != signingKey.Address {
+		return model.NewCustomError(model.PutStateErrorType, SigningKeyAddressPrefix, "key is already bound to "+string(boundBytes))
+	}
+
+	err = stub.PutState(addressKey, []byte(signingKey.Address))
+	if err != nil {
+		return model.NewCustomError(model.PutStateErrorType, SigningKeyAddressPrefix, err.Error())
+	}
+
+	signingKeyBytes, err := json.Marshal(signingKey)
+	if err != nil {
+		return model.NewCustomError(model.MarshalErrorType, SigningKeyPrefix, err.Error())
+	}
+
+	err = stub.PutState(signingKeyKey, signingKeyBytes)
+	if err != nil {
+		return model.NewCustomError(model.PutStateErrorType, SigningKeyPrefix, err.Error())
+	}
+
+	return nil
+}
+
+func GetSigningKey(stub shim.ChaincodeStubInterface, address string) (*model.SigningKey, error) {
+
+	signingKeyKey, err := stub.CreateCompositeKey(SigningKeyPrefix, []string{address})
+	if err != nil {
+		return nil, model.NewCustomError(model.CompositeKeyErrorType, SigningKeyPrefix, err.Error())
+	}
+
+	signingKeyBytes, err := stub.GetState(signingKeyKey)
+	if err != nil {
+		return nil, model.NewCustomError(model.GetStateErrorType, SigningKeyPrefix, err.Error())
+	}
+
+	if signingKeyBytes == nil {
+		return nil, model.NewCustomError(model.GetStateErrorType, SigningKeyPrefix, address+" has no signing key")
+	}
+
+	signingKey := &model.SigningKey{}
+	err = json.Unmarshal(signingKeyBytes, signingKey)
+	if err != nil {
+		return nil, model.NewCustomError(model.UnmarshalErrorType, SigningKeyPrefix, err.Error())
+	}
+
+	return signingKey, nil
+}
+
+// GetSigningKeyAddress returns the address the key is bound to
+func GetSigningKeyAddress(stub shim.ChaincodeStubInterface, curve, publicKey string) (string, error) {
+
+	addressKey, err := stub.CreateCompositeKey(SigningKeyAddressPrefix, []string{curve, publicKey})
+	if err != nil {
+		return "", model.NewCustomError(model.CompositeKeyErrorType, SigningKeyAddressPrefix, err.Error())
+	}
+
+	addressBytes, err := stub.GetState(addressKey)
+	if err != nil {
+		return "", model.NewCustomError(model.GetStateErrorType, SigningKeyAddressPrefix, err.Error())
+	}
+
+	if addressBytes == nil {
+		return "", model.NewCustomError(model.GetStateErrorType, SigningKeyAddressPrefix, "key is not bound to any address")
+	}
+
+	return string(addressBytes), nil
+}
+
+func SaveNonce(stub shim.ChaincodeStubInterface, address string, nonce *big.Int) error {
+
+	// create composite key for nonce - nonce/{address}
+	nonceKey, err := stub.CreateCompositeKey(NoncePrefix, []string{address})
+	if err != nil {
+		return model.NewCustomError(model.CompositeKeyErrorType, NoncePrefix, err.Error())
+	}
+
+	err = stub.PutState(nonceKey, []byte(nonce.String()))
+	if err != nil {
+		return model.NewCustomError(model.PutStateErrorType, NoncePrefix, err.Error())
+	}
+
+	return nil
+}
+
+// GetNonce returns the next nonce address must sign, zero if address never signed
+func GetNonce(stub shim.ChaincodeStubInterface, address string) (*big.Int, error) {
+
+	nonceKey, err := stub.CreateCompositeKey(NoncePrefix, []string{address})
+	if err != nil {
+		return nil, model.NewCustomError(model.CompositeKeyErrorType, NoncePrefix, err.Error())
+	}
+
+	nonceBytes, err := stub.GetState(nonceKey)
+	if err != nil {
+		return nil, model.NewCustomError(model.GetStateErrorType, NoncePrefix, err.Error())
+	}
+
+	if nonceBytes == nil {
+		nonceBytes = []byte("0")
+	}
+
+	nonce, ok := new(big.Int).SetString(string(nonceBytes), 10)
+	if !ok {
+		return nil, model.NewCustomError(model.ConvertErrorType, NoncePrefix, "invalid nonce "+string(nonceBytes))
+	}
+
+	return nonce, nil
+}
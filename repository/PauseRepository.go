@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"hyperledger_dapp/model"
+	"strconv"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+const PausedPrefix = "paused"
+
+func SavePaused(stub shim.ChaincodeStubInterface, paused bool) error {
+
+	// create composite key for pause flag - paused
+	pausedKey, err := stub.CreateCompositeKey(PausedPrefix, []string{})
+	if err != nil {
+		return model.NewCustomError(model.CompositeKeyErrorType, PausedPrefix, err.Error())
+	}
+
+	err = stub.PutState(pausedKey, []byte(strconv.FormatBool(paused)))
+	if err != nil {
+		return model.NewCustomError(model.PutStateErrorType, PausedPrefix, err.Error())
+	}
+
+	return nil
+}
+
+func GetPaused(stub shim.ChaincodeStubInterface) (bool, error) {
+
+	pausedKey, err := stub.CreateCompositeKey(PausedPrefix, []string{})
+	if err != nil {
+		return false, model.NewCustomError(model.CompositeKeyErrorType, PausedPrefix, err.Error())
+	}
+
+	pausedBytes, err := stub.GetState(pausedKey)
+	if err != nil {
+		return false, model.NewCustomError(model.GetStateErrorType, PausedPrefix, err.Error())
+	}
+
+	// no flag means not paused
+	if pausedBytes == nil {
+		return false, nil
+	}
+
+	paused, err := strconv.ParseBool(string(pausedBytes))
+	if err != nil {
+		return false, model.NewCustomError(model.ConvertErrorType, PausedPrefix, err.Error())
+	}
+
+	return paused, nil
+}
@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"hyperledger_dapp/model"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+const (
+	RolePrefix      = "role"
+	TokenRolePrefix = "tokenRole"
+)
+
+// roleKeyPrefix returns the object type and the attributes of the keys of role, tokenName is empty for a chaincode wide role
+// chaincode wide role - role/{role}/{address}, role of token - tokenRole/{tokenName}/{role}/{address}
+func roleKeyPrefix(tokenName, role string) (string, []string) {
+
+	if tokenName == "" {
+		return RolePrefix, []string{role}
+	}
+
+	return TokenRolePrefix, []string{tokenName, role}
+}
+
+func createRoleKey(stub shim.ChaincodeStubInterface, tokenName, role, address string) (string, error) {
+
+	objectType, attributes := roleKeyPrefix(tokenName, role)
+	key, err := stub.CreateCompositeKey(objectType, append(attributes, address))
+	if err != nil {
+		return "", model.NewCustomError(model.CompositeKeyErrorType, objectType, err.Error())
+	}
+
+	return key, nil
+}
+
+func GrantRole(stub shim.ChaincodeStubInterface, tokenName, role, address string) error {
+
+	roleKey, err := createRoleKey(stub, tokenName, role, address)
+	if err != nil {
+		return err
+	}
+
+	err = stub.PutState(roleKey, []byte(address))
+	if err != nil {
+		return model.NewCustomError(model.PutStateErrorType, RolePrefix, err.Error())
+	}
+
+	return nil
+}
+
+func RevokeRole(stub shim.ChaincodeStubInterface, tokenName, role, address string) error {
+
+	roleKey, err := createRoleKey(stub, tokenName, role, address)
+	if err != nil {
+		return err
+	}
+
+	err = stub.DelState(roleKey)
+	if err != nil {
+		return model.NewCustomError(model.DelStateErrorType, RolePrefix, err.Error())
+	}
+
+	return nil
+}
+
+func HasRole(stub shim.ChaincodeStubInterface, tokenName, role, address string) (bool, error) {
+
+	roleKey, err := createRoleKey(stub, tokenName, role, address)
+	if err != nil {
+		return false, err
+	}
+
+	roleBytes, err := stub.GetState(roleKey)
+	if err != nil {
+		return false, model.NewCustomError(model.GetStateErrorType, RolePrefix, err.Error())
+	}
+
+	return roleBytes != nil, nil
+}
+
+func GetRoleMembers(stub shim.ChaincodeStubInterface, tokenName, role string) ([]string, error) {
+
+	// get all members of role (format is iterator)
+	objectType, attributes := roleKeyPrefix(tokenName, role)
+	roleIterator, err := stub.GetStateByPartialCompositeKey(objectType, attributes)
+	if err != nil {
+		return nil, model.NewCustomError(model.GetStateErrorType, objectType, err.Error())
+	}
+	defer roleIterator.Close()
+
+	members := []string{}
+	for roleIterator.HasNext() {
+		roleKV, err := roleIterator.Next()
+		if err != nil {
+			return nil, model.NewCustomError(model.GetStateErrorType, objectType, err.Error())
+		}
+		members = append(members, string(roleKV.GetValue()))
+	}
+
+	return members, nil
+}
@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"hyperledger_dapp/model"
+	"math/big"
+	"strconv"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+// SnapshotPrefix is the namespace of snapshot ids and checkpoints
+// snapshot/id/{tokenName}, snapshot/balance/{tokenName}/{owner}/..., snapshot/supply/{tokenName}/...
+const SnapshotPrefix = "snapshot"
+
+func SaveSnapshotID(stub shim.ChaincodeStubInterface, tokenName string, id int64) error {
+
+	idKey, err := stub.CreateCompositeKey(SnapshotPrefix, []string{"id", tokenName})
+	if err != nil {
+		return model.NewCustomError(model.CompositeKeyErrorType, SnapshotPrefix, err.Error())
+	}
+
+	err = stub.PutState(idKey, []byte(strconv.FormatInt(id, 10)))
+	if err != nil {
+		return model.NewCustomError(model.PutStateErrorType, SnapshotPrefix, err.Error())
+	}
+
+	return nil
+}
+
+// GetSnapshotID returns the current snapshot id of token, 0 if no snapshot is taken
+func GetSnapshotID(stub shim.ChaincodeStubInterface, tokenName string) (int64, error) {
+
+	idKey, err := stub.CreateCompositeKey(SnapshotPrefix, []string{"id", tokenName})
+	if err != nil {
+		return 0, model.NewCustomError(model.CompositeKeyErrorType, SnapshotPrefix, err.Error())
+	}
+
+	idBytes, err := stub.GetState(idKey)
+	if err != nil {
+		return 0, model.NewCustomError(model.GetStateErrorType, SnapshotPrefix, err.Error())
+	}
+
+	if idBytes == nil {
+		return 0, nil
+	}
+
+	id, err := strconv.ParseInt(string(idBytes), 10, 64)
+	if err != nil {
+		return 0, model.NewCustomError(model.ConvertErrorType, SnapshotPrefix, err.Error())
+	}
+
+	return id, nil
+}
+
+// GetBalanceAt returns the balance of owner when snapshot id was taken
+func GetBalanceAt(stub shim.ChaincodeStubInterface, tokenName, owner string, id int64) (*big.Int, error) {
+
+	checkpoint, err := newCheckpoints(SnapshotPrefix, "balance", tokenName, owner).lowerLookup(stub, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// no change after the snapshot, so current balance is the snapshot balance
+	if checkpoint == nil {
+		return GetBalance(stub, tokenName, owner, true)
+	}
+
+	return checkpoint.GetValue(), nil
+}
+
+// GetTotalSupplyAt returns the total supply of token when snapshot id was taken
+func GetTotalSupplyAt(stub shim.ChaincodeStubInterface, tokenName string, id int64) (*big.Int, error) {
+
+	checkpoint, err := newCheckpoints(SnapshotPrefix, "supply", tokenName).lowerLookup(stub, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if checkpoint == nil {
+		return GetERC20TotalSupply(stub, tokenName)
+	}
+
+	return checkpoint.GetValue(), nil
+}
+
+// updateSnapshot records value, the value before the first change after the current snapshot
+// later changes until the next snapshot do not write anything, and do not read value
+func updateSnapshot(stub shim.ChaincodeStubInterface, tokenName string, list checkpoints, value func() (*big.Int, error)) error {
+
+	id, err := GetSnapshotID(stub, tokenName)
+	if err != nil {
+		return err
+	}
+
+	if id == 0 {
+		return nil
+	}
+
+	last, length, err := list.last(stub)
+	if err != nil {
+		return err
+	}
+
+	if last != nil && last.Key >= id {
+		return nil
+	}
+
+	curValue, err := value()
+	if err != nil {
+		return err
+	}
+
+	return list.put(stub, length, length, model.NewCheckpoint(id, curValue))
+}
@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"encoding/json"
+	"hyperledger_dapp/model"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+const VestingPrefix = "vesting"
+
+func SaveVesting(stub shim.ChaincodeStubInterface, vesting *model.Vesting) error {
+
+	// create composite key for vesting - vesting/{id}
+	vestingKey, err := stub.CreateCompositeKey(VestingPrefix, []string{vesting.ID})
+	if err != nil {
+		return model.NewCustomError(model.CompositeKeyErrorType, VestingPrefix, err.Error())
+	}
+
+	vestingBytes, err := json.Marshal(vesting)
+	if err != nil {
+		return model.NewCustomError(model.MarshalErrorType, VestingPrefix, err.Error())
+	}
+
+	err = stub.PutState(vestingKey, vestingBytes)
+	if err != nil {
+		return model.NewCustomError(model.PutStateErrorType, VestingPrefix, err.Error())
+	}
+
+	return nil
+}
+
+func GetVesting(stub shim.ChaincodeStubInterface, id string) (*model.Vesting, error) {
+
+	vestingKey, err := stub.CreateCompositeKey(VestingPrefix, []string{id})
+	if err != nil {
+		return nil, model.NewCustomError(model.CompositeKeyErrorType, VestingPrefix, err.Error())
+	}
+
+	vestingBytes, err := stub.GetState(vestingKey)
+	if err != nil {
+		return nil, model.NewCustomError(model.GetStateErrorType, VestingPrefix, err.Error())
+	}
+
+	if vestingBytes == nil {
+		return nil, model.NewCustomError(model.GetStateErrorType, VestingPrefix, id+" does not exist")
+	}
+
+	vesting := &model.Vesting{}
+	err = json.Unmarshal(vestingBytes, vesting)
+	if err != nil {
+		return nil, model.NewCustomError(model.UnmarshalErrorType, VestingPrefix, err.Error())
+	}
+
+	return vesting, nil
+}
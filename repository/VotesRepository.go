@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"fmt"
+	"hyperledger_dapp/model"
+	"math/big"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+const (
+	DelegatePrefix = "delegate"
+	VotesPrefix    = "votes"
+)
+
+func SaveDelegate(stub shim.ChaincodeStubInterface, tokenName, delegator, delegatee string) error {
+
+	// create composite key for delegate - delegate/{tokenName}/{delegator}
+	delegateKey, err := stub.CreateCompositeKey(DelegatePrefix, []string{tokenName, delegator})
+	if err != nil {
+		return model.NewCustomError(model.CompositeKeyErrorType, DelegatePrefix, err.Error())
+	}
+
+	err = stub.PutState(delegateKey, []byte(delegatee))
+	if err != nil {
+		return model.NewCustomError(model.PutStateErrorType, DelegatePrefix, err.Error())
+	}
+
+	return nil
+}
+
+// GetDelegate returns the delegatee of delegator, empty if delegator did not delegate
+func GetDelegate(stub shim.ChaincodeStubInterface, tokenName, delegator string) (string, error) {
+
+	delegateKey, err := stub.CreateCompositeKey(DelegatePrefix, []string{tokenName, delegator})
+	if err != nil {
+		return "", model.NewCustomError(model.CompositeKeyErrorType, DelegatePrefix, err.Error())
+	}
+
+	delegateBytes, err := stub.GetState(delegateKey)
+	if err != nil {
+		return "", model.NewCustomError(model.GetStateErrorType, DelegatePrefix, err.Error())
+	}
+
+	return string(delegateBytes), nil
+}
+
+// maxBackdatedCheckpoints is the number of later checkpoints a backdated change can be added to
+const maxBackdatedCheckpoints = 10
+
+// SaveVotes records votes of delegatee at timestamp(unix seconds)
+// checkpoints are saved under votes/{tokenName}/{delegatee}/..., one per second at most
+func SaveVotes(stub shim.ChaincodeStubInterface, tokenName, delegatee string, votes *big.Int, timestamp int64) error {
+
+	list := newCheckpoints(VotesPrefix, tokenName, delegatee)
+
+	last, length, err := list.last(stub)
+	if err != nil {
+		return err
+	}
+
+	// a second change in the same second replaces the checkpoint
+	if last == nil || last.Key <= timestamp {
+		index := length
+		if last != nil && last.Key == timestamp {
+			index = length - 1
+		}
+
+		return list.put(stub, index, length, model.NewCheckpoint(timestamp, votes))
+	}
+
+	// the client sets the transaction timestamp, so it is not monotonic
+	// a backdated change is inserted at its timestamp and added to the later checkpoints,
+	// so a future dated transaction does not pin the checkpoints after it
+	delta := new(big.Int).Sub(votes, last.GetValue())
+
+	later := []*model.Checkpoint{}
+	index := length
+	for index > 0 {
+		checkpoint, err := list.get(stub, index-1)
+		if err != nil {
+			return err
+		}
+
+		if checkpoint.Key <= timestamp {
+			break
+		}
+
+		if len(later) == maxBackdatedCheckpoints {
+			return model.NewCustomError(model.PutStateErrorType, VotesPrefix, fmt.Sprintf("timestamp %d is before more than %d checkpoints", timestamp, maxBackdatedCheckpoints))
+		}
+
+		later = append(later, checkpoint)
+		index--
+	}
+
+	// previous is the checkpoint at or before timestamp, its value is the votes before the change
+	previous := new(big.Int)
+	replace := false
+	if index > 0 {
+		checkpoint, err := list.get(stub, index-1)
+		if err != nil {
+			return err
+		}
+		previous = checkpoint.GetValue()
+		replace = checkpoint.Key == timestamp
+	}
+
+	// later checkpoints move up by one unless the change replaces a checkpoint of the same second
+	shift := int64(1)
+	if replace {
+		shift = 0
+		index--
+	}
+
+	for i, checkpoint := range later {
+		value := new(big.Int).Add(checkpoint.GetValue(), delta)
+		err = list.put(stub, length-1-int64(i)+shift, length, model.NewCheckpoint(checkpoint.Key, value))
+		if err != nil {
+			return err
+		}
+	}
+
+	return list.put(stub, index, length, model.NewCheckpoint(timestamp, previous.Add(previous, delta)))
+}
+
+// GetVotes returns the current votes of delegatee
+func GetVotes(stub shim.ChaincodeStubInterface, tokenName, delegatee string) (*big.Int, error) {
+
+	last, _, err := newCheckpoints(VotesPrefix, tokenName, delegatee).last(stub)
+	if err != nil {
+		return nil, err
+	}
+
+	if last == nil {
+		return new(big.Int), nil
+	}
+
+	return last.GetValue(), nil
+}
+
+// GetPastVotes returns the votes of delegatee at timestamp(unix seconds)
+func GetPastVotes(stub shim.ChaincodeStubInterface, tokenName, delegatee string, timestamp int64) (*big.Int, error) {
+
+	checkpoint, err := newCheckpoints(VotesPrefix, tokenName, delegatee).upperLookup(stub, timestamp)
+	if err != nil {
+		return nil, err
+	}
+
+	if checkpoint == nil {
+		return new(big.Int), nil
+	}
+
+	return checkpoint.GetValue(), nil
+}
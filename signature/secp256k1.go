@@ -0,0 +1,51 @@
+package signature
+
+import (
+	"math/big"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v3"
+	"github.com/decred/dcrd/dcrec/secp256k1/v3/ecdsa"
+)
+
+// secp256k1 is y^2 = x^3 + 7, which crypto/elliptic does not provide
+// the curve arithmetic of dcrd is used, it runs in constant time
+
+// unmarshalSecp256k1 decodes 65 bytes uncompressed or 33 bytes compressed point
+// it returns nil if data is not a point on the curve
+func unmarshalSecp256k1(data []byte) (*big.Int, *big.Int) {
+
+	publicKey, err := secp256k1.ParsePubKey(data)
+	if err != nil {
+		return nil, nil
+	}
+
+	return publicKey.X(), publicKey.Y()
+}
+
+// verifySecp256k1 is ECDSA verification of 32 bytes digest
+// r and s must be in [1, n - 1], s may be in either half of the order like on P-256
+func verifySecp256k1(x, y *big.Int, digest []byte, r, s *big.Int) bool {
+
+	publicKey, err := secp256k1.ParsePubKey((&PublicKey{X: x, Y: y}).bytes())
+	if err != nil {
+		return false
+	}
+
+	var rScalar, sScalar secp256k1.ModNScalar
+	if !setScalar(&rScalar, r) || !setScalar(&sScalar, s) {
+		return false
+	}
+
+	return ecdsa.NewSignature(&rScalar, &sScalar).Verify(digest, publicKey)
+}
+
+// setScalar sets value to scalar, it returns false unless value is in [1, n - 1]
+func setScalar(scalar *secp256k1.ModNScalar, value *big.Int) bool {
+
+	if value.Sign() <= 0 || value.BitLen() > 256 {
+		return false
+	}
+
+	overflow := scalar.SetByteSlice(value.Bytes())
+	return !overflow && !scalar.IsZero()
+}
@@ -0,0 +1,153 @@
+package signature
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"encoding/asn1"
+	"encoding/binary"
+	"encoding/hex"
+	"hyperledger_dapp/model"
+	"math/big"
+)
+
+const (
+	P256      = "P-256"
+	Secp256k1 = "secp256k1"
+)
+
+// PublicKey is an ECDSA public key on P-256 or secp256k1
+type PublicKey struct {
+	Curve string
+	X     *big.Int
+	Y     *big.Int
+}
+
+// String encodes the key as hex of uncompressed point
+func (publicKey *PublicKey) String() string {
+	return hex.EncodeToString(publicKey.bytes())
+}
+
+// bytes returns the uncompressed point
+func (publicKey *PublicKey) bytes() []byte {
+	point := make([]byte, 65)
+	point[0] = 4
+	publicKey.X.FillBytes(point[1:33])
+	publicKey.Y.FillBytes(point[33:])
+	return point
+}
+
+// PermitDigest is the sha256 hash the owner signs to permit an allowance
+// the domain of tag, channel ID and chaincode name comes first,
+// so a signature for one deployment can not be replayed on another chaincode of the channel
+func PermitDigest(channelID, chaincodeName, tokenName, owner, spender, value, nonce, deadline string) []byte {
+	return digestOf("permit", channelID, chaincodeName, nonce, tokenName, owner, spender, value, deadline)
+}
+
+// BindDigest is the sha256 hash the key holder signs to bind the key to address
+// binding uses no nonce, its nonce field is empty
+func BindDigest(channelID, chaincodeName, address, curve, publicKeyHex string) []byte {
+	return digestOf("bindSigningKey", channelID, chaincodeName, "", address, curve, publicKeyHex)
+}
+
+// RequestDigest is the sha256 hash the signer signs to relay a serialized request
+// nonce is the nonce of the request
+func RequestDigest(channelID, chaincodeName, nonce, request string) []byte {
+	return digestOf("executeSigned", channelID, chaincodeName, nonce, request)
+}
+
+// digestOf hashes tag, channel ID, chaincode name and nonce at fixed positions followed by fields
+// each field is prefixed by its length as 4 bytes big endian, so different fields never encode the same bytes
+func digestOf(tag, channelID, chaincodeName, nonce string, fields ...string) []byte {
+
+	hash := sha256.New()
+	length := make([]byte, 4)
+	for _, field := range append([]string{tag, channelID, chaincodeName, nonce}, fields...) {
+		binary.BigEndian.PutUint32(length, uint32(len(field)))
+		hash.Write(length)
+		hash.Write([]byte(field))
+	}
+
+	return hash.Sum(nil)
+}
+
+// MarshalPublicKey encodes ECDSA public key as hex of uncompressed point
+func MarshalPublicKey(publicKey *ecdsa.PublicKey) string {
+	return hex.EncodeToString(elliptic.Marshal(publicKey.Curve, publicKey.X, publicKey.Y))
+}
+
+// ParsePublicKey decodes hex of uncompressed or compressed point on curve
+func ParsePublicKey(curve, publicKeyHex string) (*PublicKey, error) {
+
+	publicKeyBytes, err := hex.DecodeString(publicKeyHex)
+	if err != nil {
+		return nil, model.NewCustomError(model.ConvertErrorType, "public key", err.Error())
+	}
+
+	var x, y *big.Int
+	switch curve {
+	case P256:
+		if len(publicKeyBytes) == 33 {
+			x, y = elliptic.UnmarshalCompressed(elliptic.P256(), publicKeyBytes)
+		} else {
+			x, y = elliptic.Unmarshal(elliptic.P256(), publicKeyBytes)
+		}
+	case Secp256k1:
+		x, y = unmarshalSecp256k1(publicKeyBytes)
+	default:
+		return nil, model.NewCustomError(model.ConvertErrorType, "curve", "unsupported curve "+curve)
+	}
+
+	if x == nil {
+		return nil, model.NewCustomError(model.ConvertErrorType, "public key", "invalid point on "+curve)
+	}
+
+	return &PublicKey{Curve: curve, X: x, Y: y}, nil
+}
+
+// Verify checks hex of ECDSA signature of digest
+// signature is ASN.1 DER or 64 bytes of r || s
+func Verify(publicKey *PublicKey, digest []byte, signatureHex string) error {
+
+	signatureBytes, err := hex.DecodeString(signatureHex)
+	if err != nil {
+		return model.NewCustomError(model.ConvertErrorType, "signature", err.Error())
+	}
+
+	r, s, err := parseSignature(signatureBytes)
+	if err != nil {
+		return err
+	}
+
+	valid := false
+	switch publicKey.Curve {
+	case P256:
+		ecdsaKey := &ecdsa.PublicKey{Curve: elliptic.P256(), X: publicKey.X, Y: publicKey.Y}
+		valid = ecdsa.Verify(ecdsaKey, digest, r, s)
+	case Secp256k1:
+		valid = verifySecp256k1(publicKey.X, publicKey.Y, digest, r, s)
+	}
+
+	if !valid {
+		return model.NewCustomError(model.VerifyErrorType, "signature", "signature does not match the signer's key")
+	}
+
+	return nil
+}
+
+func parseSignature(signatureBytes []byte) (*big.Int, *big.Int, error) {
+
+	if len(signatureBytes) == 64 {
+		return new(big.Int).SetBytes(signatureBytes[:32]), new(big.Int).SetBytes(signatureBytes[32:]), nil
+	}
+
+	var sig struct {
+		R, S *big.Int
+	}
+	rest, err := asn1.Unmarshal(signatureBytes, &sig)
+	if err != nil || len(rest) != 0 {
+		return nil, nil, model.NewCustomError(model.ConvertErrorType, "signature", "signature must be DER or r || s")
+	}
+
+	return sig.R, sig.S, nil
+}
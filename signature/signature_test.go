@@ -0,0 +1,120 @@
+package signature
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v3"
+	"github.com/decred/dcrd/dcrec/secp256k1/v3/ecdsa"
+)
+
+// vector made with openssl: ecparam -name secp256k1 -genkey, dgst -sha256 -sign
+const (
+	secp256k1Message      = "secp256k1 test vector"
+	secp256k1Uncompressed = "04f8e3dfecc8a4abd6c615aec109dc17ad22945da20587713f1585746c6e59a5881e2cc9882937c6b4704cb1beedf2e376b92be49242de186bb6b024f54fa9fa1a"
+	secp256k1Compressed   = "02f8e3dfecc8a4abd6c615aec109dc17ad22945da20587713f1585746c6e59a588"
+	secp256k1Signature    = "30450220409cb43512f1f954b2f33e18d6333c43663c5e7eff1aeb1befa97c2c59635709022100efc22523763acb10187264a59751008b786741338c20d06c13749e2b78ec421c"
+)
+
+func TestVerifySecp256k1(t *testing.T) {
+	digest := sha256.Sum256([]byte(secp256k1Message))
+
+	for _, publicKeyHex := range []string{secp256k1Uncompressed, secp256k1Compressed} {
+		publicKey, err := ParsePublicKey(Secp256k1, publicKeyHex)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = Verify(publicKey, digest[:], secp256k1Signature)
+		if err != nil {
+			t.Fatal("valid signature rejected", err)
+		}
+	}
+
+	publicKey, _ := ParsePublicKey(Secp256k1, secp256k1Uncompressed)
+	tampered := sha256.Sum256([]byte(secp256k1Message + "!"))
+	if Verify(publicKey, tampered[:], secp256k1Signature) == nil {
+		t.Fatal("signature of another message must be rejected")
+	}
+}
+
+func TestSecp256k1EdgeCases(t *testing.T) {
+	digest := sha256.Sum256([]byte(secp256k1Message))
+	n := secp256k1.S256().N
+
+	// points which are not on the curve are rejected when parsed
+	for _, publicKeyHex := range []string{
+		secp256k1Uncompressed[:len(secp256k1Uncompressed)-2] + "1b",
+		"02fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2f",
+		"05" + secp256k1Compressed[2:],
+		secp256k1Compressed[:20],
+	} {
+		if _, err := ParsePublicKey(Secp256k1, publicKeyHex); err == nil {
+			t.Fatal("invalid point must be rejected", publicKeyHex)
+		}
+	}
+
+	publicKey, _ := ParsePublicKey(Secp256k1, secp256k1Uncompressed)
+	r, s, _ := parseSignature(mustDecode(t, secp256k1Signature))
+
+	rs := func(r, s *big.Int) string {
+		signatureBytes := make([]byte, 64)
+		r.FillBytes(signatureBytes[:32])
+		s.FillBytes(signatureBytes[32:])
+		return hex.EncodeToString(signatureBytes)
+	}
+
+	// the vector has high s, its low s twin is valid as well
+	if s.Cmp(new(big.Int).Rsh(n, 1)) <= 0 {
+		t.Fatal("vector must have high s")
+	}
+	if err := Verify(publicKey, digest[:], rs(r, new(big.Int).Sub(n, s))); err != nil {
+		t.Fatal("low s signature rejected", err)
+	}
+
+	// r and s must be in [1, n - 1]
+	for _, signatureHex := range []string{
+		rs(new(big.Int), s),
+		rs(r, new(big.Int)),
+		rs(n, s),
+		rs(r, n),
+		rs(r, new(big.Int).Add(n, big.NewInt(1))),
+	} {
+		if Verify(publicKey, digest[:], signatureHex) == nil {
+			t.Fatal("signature out of range must be rejected", signatureHex)
+		}
+	}
+
+	// signature made by dcrd verifies with the compressed key
+	privateKey, _ := secp256k1.GeneratePrivateKey()
+	compressed, _ := ParsePublicKey(Secp256k1, hex.EncodeToString(privateKey.PubKey().SerializeCompressed()))
+	signatureHex := hex.EncodeToString(ecdsa.Sign(privateKey, digest[:]).Serialize())
+	if err := Verify(compressed, digest[:], signatureHex); err != nil {
+		t.Fatal("valid signature rejected", err)
+	}
+}
+
+func mustDecode(t *testing.T, value string) []byte {
+	decoded, err := hex.DecodeString(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return decoded
+}
+
+func TestDigestFieldsDoNotCollide(t *testing.T) {
+	// moving the separator between fields changes the digest
+	first := PermitDigest("channel", "erc20", "token\nowner", "spender", "1", "2", "0", "100")
+	second := PermitDigest("channel", "erc20", "token", "owner\nspender", "1", "2", "0", "100")
+	if bytes.Equal(first, second) {
+		t.Fatal("digests of different fields must differ")
+	}
+
+	// the chaincode name and the nonce are fields of their own
+	if bytes.Equal(RequestDigest("channel", "erc20", "1", "request"), RequestDigest("channel", "erc201", "", "request")) {
+		t.Fatal("digests of different chaincodes must differ")
+	}
+}
@@ -2,19 +2,84 @@ package util
 
 import (
 	"hyperledger_dapp/model"
+	"math/big"
 	"strconv"
 )
 
-func ConvertToPositive(name, value string) (*int, error) {
-	intValue, err := strconv.Atoi(value)
+// MaxAmount is the largest amount the chaincode can hold (2^256 - 1)
+var MaxAmount = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))
 
+// MaxPageSize is the largest page a paginated query can return
+const MaxPageSize = 1000
+
+// MaxBatchSize is the largest number of transfers a batch can run
+const MaxBatchSize = 1000
+
+func ConvertToPositive(name, value string) (*big.Int, error) {
+	intValue, err := ConvertToAmount(name, value)
 	if err != nil {
-		return nil, model.NewCustomError(model.ConvertErrorType, name, " must be integer")
+		return nil, err
 	}
 
-	if intValue <= 0 {
+	if intValue.Sign() <= 0 {
 		return nil, model.NewCustomError(model.ConvertErrorType, name, " must be positive")
 	}
 
-	return &intValue, nil
+	return intValue, nil
+}
+
+// ConvertToAmount parses value as an amount which can be zero
+func ConvertToAmount(name, value string) (*big.Int, error) {
+	intValue, ok := new(big.Int).SetString(value, 10)
+
+	if !ok {
+		return nil, model.NewCustomError(model.ConvertErrorType, name, " must be integer")
+	}
+
+	if intValue.Sign() < 0 {
+		return nil, model.NewCustomError(model.ConvertErrorType, name, " cannot be negative")
+	}
+
+	if intValue.Cmp(MaxAmount) > 0 {
+		return nil, model.NewCustomError(model.OverflowErrorType, name, " exceeds 2^256-1")
+	}
+
+	return intValue, nil
+}
+
+// AddAmount returns a + b, error if the result exceeds MaxAmount
+func AddAmount(name string, a, b *big.Int) (*big.Int, error) {
+	result := new(big.Int).Add(a, b)
+
+	if result.Cmp(MaxAmount) > 0 {
+		return nil, model.NewCustomError(model.OverflowErrorType, name, "result exceeds 2^256-1")
+	}
+
+	return result, nil
+}
+
+// SubAmount returns a - b, error if the result is negative
+func SubAmount(name string, a, b *big.Int) (*big.Int, error) {
+	result := new(big.Int).Sub(a, b)
+
+	if result.Sign() < 0 {
+		return nil, model.NewCustomError(model.UnderflowErrorType, name, name+" is not sufficient")
+	}
+
+	return result, nil
+}
+
+// ConvertToPageSize parses value as a page size between 1 and MaxPageSize
+func ConvertToPageSize(name, value string) (int32, error) {
+	pageSize, err := strconv.Atoi(value)
+
+	if err != nil {
+		return 0, model.NewCustomError(model.ConvertErrorType, name, " must be integer")
+	}
+
+	if pageSize <= 0 || pageSize > MaxPageSize {
+		return 0, model.NewCustomError(model.ConvertErrorType, name, " must be between 1 and "+strconv.Itoa(MaxPageSize))
+	}
+
+	return int32(pageSize), nil
 }